@@ -1,73 +1,117 @@
-package config
-
-import (
-	"fmt"
-	"os"
-
-	"github.com/joho/godotenv"
-)
-
-type Config struct {
-	Port        string
-	DatabaseURL string
-	SupabaseURL string
-	JWTSecret   string
-}
-
-func Load() (*Config, error) {
-	// Carga archivo .env si estamos en local
-	_ = godotenv.Load()
-
-	// 1. Puerto
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
-
-	// 2. URL de Supabase
-	supabaseURL := os.Getenv("SUPABASE_URL")
-	if supabaseURL == "" {
-		return nil, fmt.Errorf("SUPABASE_URL es requerida")
-	}
-
-	// 3. Base de Datos
-	var dbURL string
-
-	dbUser := os.Getenv("DB_USER")
-	dbPass := os.Getenv("DB_PASSWORD")
-	dbHost := os.Getenv("DB_HOST")
-	dbPort := os.Getenv("DB_PORT")
-	dbName := os.Getenv("DB_NAME")
-
-	if dbUser != "" && dbPass != "" && dbHost != "" {
-
-		if dbPort == "" {
-			dbPort = "5432"
-		}
-		if dbName == "" {
-			dbName = "postgres"
-		}
-
-		dbURL = fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=require",
-			dbUser, dbPass, dbHost, dbPort, dbName)
-
-	} else {
-		dbURL = os.Getenv("DATABASE_URL")
-	}
-
-	if dbURL == "" {
-		return nil, fmt.Errorf("No se encontró configuración de BD (ni DB_* vars ni DATABASE_URL)")
-	}
-
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		return nil, fmt.Errorf("SUPABASE_JWT_SECRET es requerido")
-	}
-
-	return &Config{
-		Port:        port,
-		DatabaseURL: dbURL,
-		SupabaseURL: supabaseURL,
-		JWTSecret:   jwtSecret,
-	}, nil
-}
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+type Config struct {
+	Port        string
+	DatabaseURL string
+	SupabaseURL string
+	JWTSecret   string
+
+	// Intervalo del scheduler que materializa las plantillas recurrentes
+	SchedulerInterval time.Duration
+
+	// Días que un elemento borrado permanece en la papelera antes de purgarse
+	TrashRetentionDays int
+
+	// Tiempo que se guarda la respuesta de una petición con Idempotency-Key
+	IdempotencyTTL time.Duration
+}
+
+func Load() (*Config, error) {
+	// Carga archivo .env si estamos en local
+	_ = godotenv.Load()
+
+	// 1. Puerto
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	// 2. URL de Supabase
+	supabaseURL := os.Getenv("SUPABASE_URL")
+	if supabaseURL == "" {
+		return nil, fmt.Errorf("SUPABASE_URL es requerida")
+	}
+
+	// 3. Base de Datos
+	var dbURL string
+
+	dbUser := os.Getenv("DB_USER")
+	dbPass := os.Getenv("DB_PASSWORD")
+	dbHost := os.Getenv("DB_HOST")
+	dbPort := os.Getenv("DB_PORT")
+	dbName := os.Getenv("DB_NAME")
+
+	if dbUser != "" && dbPass != "" && dbHost != "" {
+
+		if dbPort == "" {
+			dbPort = "5432"
+		}
+		if dbName == "" {
+			dbName = "postgres"
+		}
+
+		dbURL = fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=require",
+			dbUser, dbPass, dbHost, dbPort, dbName)
+
+	} else {
+		dbURL = os.Getenv("DATABASE_URL")
+	}
+
+	if dbURL == "" {
+		return nil, fmt.Errorf("No se encontró configuración de BD (ni DB_* vars ni DATABASE_URL)")
+	}
+
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if jwtSecret == "" {
+		return nil, fmt.Errorf("SUPABASE_JWT_SECRET es requerido")
+	}
+
+	// 4. Scheduler de plantillas (minutos)
+	schedulerMin := 60
+	if raw := os.Getenv("SCHEDULER_INTERVAL_MIN"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("SCHEDULER_INTERVAL_MIN inválido: %s", raw)
+		}
+		schedulerMin = n
+	}
+
+	// 5. Retención de la papelera (días)
+	retentionDays := 30
+	if raw := os.Getenv("TRASH_RETENTION_DAYS"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("TRASH_RETENTION_DAYS inválido: %s", raw)
+		}
+		retentionDays = n
+	}
+
+	// 6. Idempotency-Key (horas)
+	idempotencyHours := 24
+	if raw := os.Getenv("IDEMPOTENCY_TTL_HOURS"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("IDEMPOTENCY_TTL_HOURS inválido: %s", raw)
+		}
+		idempotencyHours = n
+	}
+
+	return &Config{
+		Port:               port,
+		DatabaseURL:        dbURL,
+		SupabaseURL:        supabaseURL,
+		JWTSecret:          jwtSecret,
+		SchedulerInterval:  time.Duration(schedulerMin) * time.Minute,
+		TrashRetentionDays: retentionDays,
+		IdempotencyTTL:     time.Duration(idempotencyHours) * time.Hour,
+	}, nil
+}
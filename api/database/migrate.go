@@ -0,0 +1,86 @@
+package database
+
+import (
+	"log"
+
+	"github.com/tu-usuario/route-manager/api/domains"
+)
+
+// Migrate sincroniza el esquema con los modelos de dominio.
+// GORM solo crea tablas/columnas faltantes: nunca borra columnas ni datos.
+func Migrate() {
+	err := DB.AutoMigrate(
+		&domains.User{},
+		&domains.Route{},
+		&domains.Waypoint{},
+		&domains.RouteTemplate{},
+		&domains.RouteTemplateWaypoint{},
+		&domains.AuditLog{},
+		&domains.WorkingHours{},
+		&domains.TimeOff{},
+		&domains.TrackingLink{},
+		&domains.DeliveryAttempt{},
+		&domains.ProofOfDelivery{},
+		&domains.ProofPhoto{},
+		&domains.FleetSettings{},
+		&domains.Breadcrumb{},
+		&domains.SyncOperation{},
+		&domains.IdempotencyKey{},
+		&domains.WebhookEndpoint{},
+		&domains.OutboxEvent{},
+		&domains.WebhookDelivery{},
+		&domains.WebhookAttempt{},
+		&domains.NotificationTemplate{},
+		&domains.CustomerNotification{},
+		&domains.ContactOptOut{},
+		&domains.Notification{},
+		&domains.RouteMessage{},
+		&domains.MessageReceipt{},
+		&domains.Incident{},
+		&domains.IncidentPhoto{},
+		&domains.ChecklistTemplate{},
+		&domains.ChecklistItem{},
+		&domains.InspectionSubmission{},
+		&domains.InspectionAnswer{},
+	)
+	if err != nil {
+		log.Fatalf("❌ Error ejecutando migraciones: %v", err)
+	}
+
+	// La POD era única por parada; ahora se conservan las reemplazadas (AutoMigrate no borra índices)
+	if DB.Migrator().HasIndex(&domains.ProofOfDelivery{}, "idx_proof_of_deliveries_waypoint_id") {
+		if err := DB.Migrator().DropIndex(&domains.ProofOfDelivery{}, "idx_proof_of_deliveries_waypoint_id"); err != nil {
+			log.Fatalf("❌ Error ejecutando migraciones: %v", err)
+		}
+	}
+
+	// Los avisos al cliente eran únicos por parada; ahora también por ruta (reprogramar vuelve a avisar)
+	if DB.Migrator().HasIndex(&domains.CustomerNotification{}, "idx_customer_notification_once") {
+		if err := DB.Migrator().DropIndex(&domains.CustomerNotification{}, "idx_customer_notification_once"); err != nil {
+			log.Fatalf("❌ Error ejecutando migraciones: %v", err)
+		}
+	}
+
+	// Rutas generadas antes de template_date: se completa una por plantilla y día (si había duplicadas, quedan sin marcar)
+	if err := DB.Exec(`UPDATE routes SET template_date = d.day
+		FROM (
+			SELECT DISTINCT ON (r.template_id, r.scheduled_date::date) r.id, r.scheduled_date::date AS day
+			FROM routes r
+			WHERE r.template_id IS NOT NULL AND r.template_date IS NULL AND r.scheduled_date IS NOT NULL
+			  AND NOT EXISTS (SELECT 1 FROM routes x WHERE x.template_id = r.template_id AND x.template_date = r.scheduled_date::date)
+			ORDER BY r.template_id, r.scheduled_date::date, r.created_at
+		) d
+		WHERE routes.id = d.id`).Error; err != nil {
+		log.Fatalf("❌ Error ejecutando migraciones: %v", err)
+	}
+
+	// Las columnas de la política tenían default en la base; si alguna fila quedó sin valor, se completa
+	if err := DB.Exec("UPDATE fleet_settings SET completion_undo_window_min = 5 WHERE completion_undo_window_min IS NULL OR completion_undo_window_min = 0").Error; err != nil {
+		log.Fatalf("❌ Error ejecutando migraciones: %v", err)
+	}
+	if err := DB.Exec("UPDATE fleet_settings SET customer_notify_stops_away = 3 WHERE customer_notify_stops_away IS NULL").Error; err != nil {
+		log.Fatalf("❌ Error ejecutando migraciones: %v", err)
+	}
+
+	log.Println("✅ Migraciones aplicadas correctamente")
+}
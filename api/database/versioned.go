@@ -0,0 +1,38 @@
+package database
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/tu-usuario/route-manager/api/domains"
+)
+
+// ErrVersionConflict: otro usuario modificó la fila desde que la leímos
+var ErrVersionConflict = errors.New("la versión del registro cambió (conflicto de edición)")
+
+// SaveVersioned reemplaza a tx.Save para modelos versionados: escribe la fila completa
+// solo si la versión en BD sigue siendo la que leímos, y la incrementa en uno.
+// Si otro proceso escribió antes, devuelve ErrVersionConflict y no toca nada.
+func SaveVersioned(tx *gorm.DB, model domains.Versioned) error {
+	expected := model.GetVersion()
+	model.SetVersion(expected + 1)
+
+	result := tx.Model(model).
+		Where("version = ?", expected).
+		Select("*").
+		Omit(clause.Associations, "created_at").
+		Updates(model)
+
+	if result.Error != nil {
+		model.SetVersion(expected)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		model.SetVersion(expected)
+		return ErrVersionConflict
+	}
+
+	return nil
+}
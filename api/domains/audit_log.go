@@ -0,0 +1,45 @@
+package domains
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuditLog registra quién cambió qué (antes/después) sobre Routes, Waypoints y Users
+type AuditLog struct {
+	ID uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+
+	// Entidad afectada
+	EntityType string    `gorm:"index:idx_audit_entity;not null" json:"entity_type"` // route, waypoint, user
+	EntityID   uuid.UUID `gorm:"type:uuid;index:idx_audit_entity" json:"entity_id"`
+	Action     string    `gorm:"not null" json:"action"` // create, update, delete, assign, status, complete...
+
+	// RouteID: agrupa el historial de la ruta y de sus paradas
+	RouteID *uuid.UUID `gorm:"type:uuid;index" json:"route_id,omitempty"`
+
+	// OwnerID: Admin dueño de la flota afectada (para que cada Admin vea solo lo suyo)
+	OwnerID *uuid.UUID `gorm:"type:uuid;index" json:"owner_id,omitempty"`
+
+	// Contexto de la petición
+	ActorID   *uuid.UUID `gorm:"type:uuid;index" json:"actor_id"` // nil = proceso del sistema (scheduler)
+	IP        string     `json:"ip"`
+	RequestID string     `gorm:"index" json:"request_id"`
+
+	// Changes: {"campo": {"from": x, "to": y}, ...}
+	Changes json.RawMessage `gorm:"type:jsonb" json:"changes"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+
+	// Relaciones
+	Actor *User `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+}
+
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) (err error) {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return
+}
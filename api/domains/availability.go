@@ -0,0 +1,56 @@
+package domains
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WorkingHours es un tramo del horario semanal del conductor (ej: lunes 08:00-17:00).
+// Si un conductor no tiene ningún tramo cargado se considera disponible todos los días.
+type WorkingHours struct {
+	ID       uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	DriverID uuid.UUID `gorm:"type:uuid;index;not null" json:"driver_id"`
+
+	Weekday   int    `gorm:"not null" json:"weekday"`    // 0 = domingo ... 6 = sábado
+	StartTime string `gorm:"not null" json:"start_time"` // "HH:MM"
+	EndTime   string `gorm:"not null" json:"end_time"`   // "HH:MM"
+}
+
+func (w *WorkingHours) BeforeCreate(tx *gorm.DB) (err error) {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	return
+}
+
+// TimeOff es una ausencia (vacaciones, licencia médica...) que solicita el conductor
+// y aprueba o rechaza su jefe. Las fechas son inclusivas.
+type TimeOff struct {
+	ID       uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	DriverID uuid.UUID `gorm:"type:uuid;index;not null" json:"driver_id"`
+
+	Type      string    `gorm:"not null" json:"type"` // vacation, sick, personal
+	StartDate time.Time `gorm:"type:date;not null" json:"start_date"`
+	EndDate   time.Time `gorm:"type:date;not null" json:"end_date"`
+	Reason    string    `json:"reason"`
+
+	Status     string     `gorm:"default:'pending';index" json:"status"` // pending, approved, rejected
+	ReviewedBy *uuid.UUID `gorm:"type:uuid" json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+	ReviewNote string     `json:"review_note,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relaciones
+	Driver *User `gorm:"foreignKey:DriverID" json:"driver,omitempty"`
+}
+
+func (t *TimeOff) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return
+}
@@ -0,0 +1,25 @@
+package domains
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Breadcrumb es una posición GPS del conductor durante una ruta en curso.
+// Se guardan ya reducidas (ver services/tracking): ID secuencial y un único índice
+// (route_id, recorded_at) para que la tabla crezca poco y se lea en orden.
+type Breadcrumb struct {
+	ID       uint64    `gorm:"primaryKey;autoIncrement" json:"-"`
+	RouteID  uuid.UUID `gorm:"type:uuid;index:idx_breadcrumb_route_time,priority:1;not null" json:"-"`
+	DriverID uuid.UUID `gorm:"type:uuid;not null" json:"-"`
+
+	Latitude   float64  `gorm:"not null" json:"lat"`
+	Longitude  float64  `gorm:"not null" json:"lng"`
+	AccuracyM  *float64 `json:"accuracy,omitempty"` // Metros
+	SpeedMps   *float64 `json:"speed,omitempty"`    // Metros/segundo
+	HeadingDeg *float64 `json:"heading,omitempty"`  // 0-360, 0 = norte
+
+	RecordedAt time.Time `gorm:"index:idx_breadcrumb_route_time,priority:2;not null" json:"recorded_at"` // Hora del dispositivo
+	CreatedAt  time.Time `json:"-"`
+}
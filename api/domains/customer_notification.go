@@ -0,0 +1,102 @@
+package domains
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Momentos en los que se avisa al cliente final
+const (
+	NotifyOnTheWay  = "on_the_way" // Su parada es la siguiente
+	NotifyStopsAway = "stops_away" // Faltan N paradas (N configurable por flota)
+	NotifyDelivered = "delivered"
+)
+
+// Canales de aviso
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+)
+
+// Estados de un aviso
+const (
+	CustomerNotifyPending  = "pending"
+	CustomerNotifySent     = "sent"
+	CustomerNotifyFailed   = "failed"    // Agotó los reintentos
+	CustomerNotifySkipped  = "skipped"   // Ya no aplica (ej: "en camino" de una parada ya resuelta)
+	CustomerNotifyOptedOut = "opted_out" // El contacto se dio de baja antes del envío
+)
+
+// NotificationTemplate es el texto que una flota usa para un evento y canal.
+// Si la flota no tiene plantilla se usa la de por defecto del servicio notify.
+type NotificationTemplate struct {
+	ID       uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	AdminID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_notification_template" json:"admin_id"`
+	Event    string    `gorm:"not null;uniqueIndex:idx_notification_template" json:"event"`
+	Channel  string    `gorm:"not null;uniqueIndex:idx_notification_template" json:"channel"`
+	Subject  string    `json:"subject,omitempty"` // Solo email
+	Body     string    `gorm:"not null" json:"body"`
+	IsActive bool      `json:"is_active"` // false: la flota no envía este aviso
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (t *NotificationTemplate) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return
+}
+
+// CustomerNotification es un aviso ya renderizado, listo para enviar (cola + registro).
+// Hay como máximo uno por ruta, parada, evento y canal: repetir el disparo no duplica el mensaje,
+// pero una parada reprogramada a otra ruta vuelve a avisar ese día.
+type CustomerNotification struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	AdminID    uuid.UUID `gorm:"type:uuid;index;not null" json:"admin_id"`
+	RouteID    uuid.UUID `gorm:"type:uuid;index;not null;uniqueIndex:idx_customer_notification_route_once" json:"route_id"`
+	WaypointID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_customer_notification_route_once" json:"waypoint_id"`
+	Event      string    `gorm:"not null;uniqueIndex:idx_customer_notification_route_once" json:"event"`
+	Channel    string    `gorm:"not null;uniqueIndex:idx_customer_notification_route_once" json:"channel"`
+
+	Recipient string `gorm:"not null" json:"recipient"`
+	Subject   string `json:"subject,omitempty"`
+	Body      string `json:"body"`
+
+	// Token del link de baja incluido en el mensaje
+	UnsubscribeToken string `gorm:"uniqueIndex;not null" json:"-"`
+
+	Status        string     `gorm:"index;not null" json:"status"`
+	AttemptCount  int        `json:"attempt_count"`
+	NextAttemptAt time.Time  `gorm:"index" json:"next_attempt_at"`
+	LastError     string     `json:"last_error,omitempty"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func (n *CustomerNotification) BeforeCreate(tx *gorm.DB) (err error) {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	return
+}
+
+// ContactOptOut: un email o teléfono que no quiere recibir avisos de la flota por ese canal
+type ContactOptOut struct {
+	ID      uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	AdminID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_contact_opt_out" json:"admin_id"`
+	Channel string    `gorm:"not null;uniqueIndex:idx_contact_opt_out" json:"channel"`
+	Contact string    `gorm:"not null;uniqueIndex:idx_contact_opt_out" json:"contact"` // Normalizado (email en minúsculas)
+	Source  string    `json:"source"`                                                  // link (el cliente) o admin
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (o *ContactOptOut) BeforeCreate(tx *gorm.DB) (err error) {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	return
+}
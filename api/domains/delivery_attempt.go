@@ -0,0 +1,45 @@
+package domains
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Motivos de entrega fallida
+const (
+	ReasonCustomerAbsent  = "customer_absent"
+	ReasonAddressNotFound = "address_not_found"
+	ReasonRefused         = "refused"
+	ReasonDamaged         = "damaged"
+	ReasonOther           = "other"
+)
+
+// DeliveryAttempt es cada intento de entrega de una parada (exitoso o fallido).
+// RouteID es la ruta en la que se hizo el intento: se conserva aunque la parada
+// luego se reprograme en otra ruta.
+type DeliveryAttempt struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	WaypointID uuid.UUID `gorm:"type:uuid;index;not null" json:"waypoint_id"`
+	RouteID    uuid.UUID `gorm:"type:uuid;index;not null" json:"route_id"`
+	DriverID   uuid.UUID `gorm:"type:uuid;not null" json:"driver_id"`
+
+	Outcome    string  `gorm:"not null" json:"outcome"` // delivered, failed, reverted (entrega deshecha)
+	ReasonCode string  `json:"reason_code,omitempty"`   // Solo si falló (ver Reason*)
+	Notes      string  `json:"notes,omitempty"`
+	PhotoURL   *string `json:"photo_url,omitempty"` // Path en el bucket (se firma al responder)
+
+	AttemptedAt time.Time `gorm:"not null" json:"attempted_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (a *DeliveryAttempt) BeforeCreate(tx *gorm.DB) (err error) {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	if a.AttemptedAt.IsZero() {
+		a.AttemptedAt = time.Now()
+	}
+	return
+}
@@ -0,0 +1,45 @@
+package domains
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FleetSettings son las políticas operativas de una flota (una fila por Admin).
+// Si la flota no tiene fila se usan los valores de DefaultFleetSettings.
+// Sin tags `default`: GORM cambiaría un 0 explícito por el default al insertar.
+type FleetSettings struct {
+	AdminID uuid.UUID `gorm:"type:uuid;primaryKey" json:"admin_id"`
+
+	// Geocerca al completar una parada:
+	// hasta AcceptRadius (+ precisión del GPS) se acepta; más lejos se marca como excepción;
+	// más allá de RejectRadius se rechaza (0 = nunca rechazar, solo marcar).
+	GeofenceAcceptRadiusM   int  `json:"geofence_accept_radius_m"`
+	GeofenceRejectRadiusM   int  `json:"geofence_reject_radius_m"`
+	GeofenceMaxAccuracyM    int  `json:"geofence_max_accuracy_m"`   // Lecturas menos precisas se marcan
+	GeofenceRequireLocation bool `json:"geofence_require_location"` // Sin GPS: rechazar (true) o marcar (false)
+
+	// Minutos durante los que el conductor puede deshacer una entrega por su cuenta
+	CompletionUndoWindowMin int `json:"completion_undo_window_min"`
+
+	// Aviso "faltan N paradas" al cliente final (0 = no enviarlo)
+	CustomerNotifyStopsAway int `json:"customer_notify_stops_away"`
+
+	// Exigir el checklist pre-viaje (sin ítems críticos fallidos) para iniciar una ruta
+	RequirePreTripInspection bool `json:"require_pre_trip_inspection"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DefaultFleetSettings devuelve la política por defecto de una flota
+func DefaultFleetSettings(adminID uuid.UUID) FleetSettings {
+	return FleetSettings{
+		AdminID:               adminID,
+		GeofenceAcceptRadiusM: 150,
+		GeofenceMaxAccuracyM:  100,
+
+		CompletionUndoWindowMin: 5,
+		CustomerNotifyStopsAway: 3,
+	}
+}
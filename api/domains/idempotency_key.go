@@ -0,0 +1,33 @@
+package domains
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Estados de una clave de idempotencia
+const (
+	IdempotencyProcessing = "processing" // La primera petición todavía se está ejecutando
+	IdempotencyCompleted  = "completed"  // Respuesta guardada para repetirla en los reintentos
+)
+
+// IdempotencyKey guarda la primera respuesta de una petición con header Idempotency-Key,
+// por usuario y clave, hasta ExpiresAt
+type IdempotencyKey struct {
+	UserID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Key    string    `gorm:"primaryKey;size:255"`
+
+	Method      string `gorm:"not null"`
+	Path        string `gorm:"not null"`
+	RequestHash string // SHA-256 del body: la misma clave con otro body es un error del cliente
+
+	Status         string `gorm:"not null"`
+	ResponseStatus int
+	ResponseBody   []byte
+	ContentType    string
+	ETag           string `gorm:"column:etag"`
+
+	CreatedAt time.Time
+	ExpiresAt time.Time `gorm:"index;not null"`
+}
@@ -0,0 +1,74 @@
+package domains
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Estados de un incidente: el Admin de la ruta lo toma (acknowledged) y lo cierra (resolved)
+const (
+	IncidentOpen         = "open"
+	IncidentAcknowledged = "acknowledged"
+	IncidentResolved     = "resolved"
+)
+
+// Incident es un problema reportado por el conductor en la calle
+// (accidente, avería, corte de calle, mercadería dañada...).
+// Las URLs de las fotos son paths del bucket; se firman al responder.
+type Incident struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	RouteID    uuid.UUID  `gorm:"type:uuid;index;not null" json:"route_id"`
+	WaypointID *uuid.UUID `gorm:"type:uuid;index" json:"waypoint_id,omitempty"` // Parada afectada (opcional)
+	AdminID    uuid.UUID  `gorm:"type:uuid;index;not null" json:"admin_id"`     // Dueño de la ruta: quien lo gestiona
+	ReporterID uuid.UUID  `gorm:"type:uuid;not null" json:"reporter_id"`
+
+	Category    string `gorm:"not null" json:"category"` // accident, breakdown, road_closure, damaged_goods, other
+	Severity    string `gorm:"not null" json:"severity"` // low, medium, high, critical
+	Description string `json:"description"`
+
+	// Posición reportada por el dispositivo
+	Latitude   *float64  `json:"latitude,omitempty"`
+	Longitude  *float64  `json:"longitude,omitempty"`
+	AccuracyM  *float64  `json:"accuracy_m,omitempty"`
+	OccurredAt time.Time `gorm:"not null" json:"occurred_at"`
+
+	// Resolución
+	Status         string     `gorm:"index;not null" json:"status"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	AcknowledgedBy *uuid.UUID `gorm:"type:uuid" json:"acknowledged_by,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	ResolvedBy     *uuid.UUID `gorm:"type:uuid" json:"resolved_by,omitempty"`
+	ResolutionNote string     `json:"resolution_note,omitempty"`
+
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relaciones
+	Reporter *User           `gorm:"foreignKey:ReporterID" json:"reporter,omitempty"`
+	Photos   []IncidentPhoto `gorm:"foreignKey:IncidentID" json:"photos"`
+}
+
+func (i *Incident) BeforeCreate(tx *gorm.DB) (err error) {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return
+}
+
+// IncidentPhoto es cada foto adjunta al incidente
+type IncidentPhoto struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	IncidentID uuid.UUID `gorm:"type:uuid;index;not null" json:"-"`
+	URL        string    `gorm:"not null" json:"url"`
+	Position   int       `json:"position"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (p *IncidentPhoto) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return
+}
@@ -0,0 +1,114 @@
+package domains
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Tipos de ítem de un checklist pre-viaje
+const (
+	ChecklistItemBoolean = "boolean" // Sí / No (falla con "No")
+	ChecklistItemText    = "text"
+	ChecklistItemPhoto   = "photo"
+	ChecklistItemNumber  = "number" // Falla si queda fuera de [MinValue, MaxValue]
+)
+
+// ChecklistTemplate es el checklist de inspección del vehículo de una flota.
+// Una flota puede tener varios, pero solo uno activo: es el que completan los conductores.
+type ChecklistTemplate struct {
+	ID       uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	AdminID  uuid.UUID `gorm:"type:uuid;index;not null" json:"admin_id"`
+	Name     string    `gorm:"not null" json:"name"`
+	IsActive bool      `gorm:"default:false" json:"is_active"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relaciones
+	Items []ChecklistItem `gorm:"foreignKey:TemplateID" json:"items"`
+}
+
+func (t *ChecklistTemplate) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return
+}
+
+// ChecklistItem es cada punto a revisar. Un ítem crítico que falla impide iniciar la ruta.
+type ChecklistItem struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	TemplateID uuid.UUID `gorm:"type:uuid;index;not null" json:"-"`
+	Position   int       `json:"position"`
+	Label      string    `gorm:"not null" json:"label"`
+	Type       string    `gorm:"not null" json:"type"` // boolean, text, photo, number
+	Required   bool      `json:"required"`
+	Critical   bool      `json:"critical"`
+
+	// Rango aceptable de los ítems numéricos (nil = sin límite)
+	MinValue *float64 `json:"min_value,omitempty"`
+	MaxValue *float64 `json:"max_value,omitempty"`
+}
+
+func (i *ChecklistItem) BeforeCreate(tx *gorm.DB) (err error) {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return
+}
+
+// InspectionSubmission es un checklist completado por el conductor antes de salir.
+// Solo cuenta el último envío del conductor asignado: si falló, puede corregir y reenviar.
+type InspectionSubmission struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	RouteID    uuid.UUID `gorm:"type:uuid;index;not null" json:"route_id"`
+	TemplateID uuid.UUID `gorm:"type:uuid;not null" json:"template_id"`
+	DriverID   uuid.UUID `gorm:"type:uuid;not null" json:"driver_id"`
+	AdminID    uuid.UUID `gorm:"type:uuid;index;not null" json:"admin_id"`
+
+	Passed         bool `json:"passed"`          // Ningún ítem crítico falló
+	FailedCount    int  `json:"failed_count"`    // Ítems fallidos (críticos o no)
+	CriticalFailed int  `json:"critical_failed"` // Ítems críticos fallidos
+
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+
+	// Relaciones
+	Answers []InspectionAnswer `gorm:"foreignKey:SubmissionID" json:"answers"`
+}
+
+func (s *InspectionSubmission) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return
+}
+
+// InspectionAnswer es la respuesta a un ítem. Copia etiqueta, tipo y criticidad
+// para que editar el checklist después no cambie lo que se respondió.
+type InspectionAnswer struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	SubmissionID uuid.UUID `gorm:"type:uuid;index;not null" json:"-"`
+	ItemID       uuid.UUID `gorm:"type:uuid;not null" json:"item_id"`
+	Label        string    `json:"label"`
+	Type         string    `json:"type"`
+	Critical     bool      `json:"critical"`
+
+	BoolValue   *bool    `json:"bool_value,omitempty"`
+	TextValue   string   `json:"text_value,omitempty"`
+	NumberValue *float64 `json:"number_value,omitempty"`
+
+	// Foto: se guarda el path del bucket y se firma al leer
+	PhotoPath string `json:"-"`
+	PhotoURL  string `gorm:"-" json:"photo_url,omitempty"`
+
+	Failed bool `json:"failed"`
+}
+
+func (a *InspectionAnswer) BeforeCreate(tx *gorm.DB) (err error) {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return
+}
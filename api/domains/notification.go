@@ -0,0 +1,30 @@
+package domains
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Notification es un aviso dentro de la app (bandeja de entrada) para un usuario.
+// ReadAt nil = no leída.
+type Notification struct {
+	ID     uuid.UUID       `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID uuid.UUID       `gorm:"type:uuid;not null;index:idx_notification_user_time,priority:1" json:"user_id"`
+	Type   string          `gorm:"not null" json:"type"` // route.assigned, driver.joined, user.pending_approval
+	Title  string          `gorm:"not null" json:"title"`
+	Body   string          `json:"body"`
+	Data   json.RawMessage `gorm:"type:jsonb" json:"data,omitempty"` // IDs para que el front navegue (route_id, user_id...)
+
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `gorm:"index:idx_notification_user_time,priority:2" json:"created_at"`
+}
+
+func (n *Notification) BeforeCreate(tx *gorm.DB) (err error) {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	return
+}
@@ -0,0 +1,69 @@
+package domains
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ProofOfDelivery es la prueba de entrega de una parada: fotos, firma del receptor,
+// su nombre en imprenta y dónde/cuándo se capturó.
+// Las URLs guardadas son paths del bucket; se firman al responder.
+type ProofOfDelivery struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	WaypointID uuid.UUID `gorm:"type:uuid;index:idx_pod_waypoint;not null" json:"waypoint_id"`
+
+	RecipientName string  `json:"recipient_name"`
+	SignatureURL  *string `json:"signature_url,omitempty"` // PNG (canvas)
+
+	// Posición y hora de captura reportadas por el dispositivo
+	Latitude   *float64  `json:"latitude,omitempty"`
+	Longitude  *float64  `json:"longitude,omitempty"`
+	AccuracyM  *float64  `json:"accuracy_m,omitempty"`
+	CapturedAt time.Time `gorm:"not null" json:"captured_at"`
+
+	// Verificación de geocerca al completar (ver FleetSettings)
+	GeofenceResult string     `gorm:"index" json:"geofence_result"` // accepted, flagged
+	GeofenceReason string     `json:"geofence_reason,omitempty"`    // outside_radius, low_accuracy, no_location
+	DistanceM      *float64   `json:"distance_m,omitempty"`         // Distancia a la parada
+	ReviewedAt     *time.Time `json:"reviewed_at,omitempty"`        // Excepción revisada por el Admin
+	ReviewedBy     *uuid.UUID `gorm:"type:uuid" json:"reviewed_by,omitempty"`
+	ReviewNote     string     `json:"review_note,omitempty"`
+
+	// Una parada tiene una sola POD vigente; al deshacer/reabrir la entrega la anterior
+	// se conserva (con sus archivos) marcada como reemplazada
+	SupersededAt     *time.Time `gorm:"index" json:"superseded_at,omitempty"`
+	SupersededReason string     `json:"superseded_reason,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+
+	Photos []ProofPhoto `gorm:"foreignKey:ProofID" json:"photos"`
+}
+
+func (p *ProofOfDelivery) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return
+}
+
+// ProofPhoto es cada foto de la prueba de entrega
+type ProofPhoto struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	ProofID   uuid.UUID `gorm:"type:uuid;index;not null" json:"-"`
+	URL       string    `gorm:"not null" json:"url"`
+	Position  int       `json:"position"` // Orden en que se tomaron
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (p *ProofPhoto) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return
+}
+
+// ActiveProof es la condición de Preload para traer solo la POD vigente de la parada
+// (ej: Preload("Proof", domains.ActiveProof))
+const ActiveProof = "superseded_at IS NULL"
@@ -1,36 +1,51 @@
-package domains
-
-import (
-	"time"
-
-	"github.com/google/uuid"
-	"gorm.io/gorm"
-)
-
-type Route struct {
-	ID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
-	CreatorID uuid.UUID  `gorm:"type:uuid;column:creator_id" json:"creator_id"`
-	DriverID  *uuid.UUID `gorm:"type:uuid;column:driver_id" json:"driver_id"`
-
-	Name                 string     `gorm:"not null" json:"name"`
-	Status               string     `gorm:"default:'draft'" json:"status"`
-	ScheduledDate        *time.Time `json:"scheduled_date"`
-	TotalDistanceKm      float64    `json:"total_distance_km"`
-	EstimatedDurationMin int        `json:"estimated_duration_min"`
-
-	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
-	DeletedAt *time.Time `gorm:"index" json:"-"`
-
-	// Relaciones
-	Creator   User       `gorm:"foreignKey:CreatorID" json:"creator,omitempty"`
-	Driver    *User      `gorm:"foreignKey:DriverID" json:"driver,omitempty"`
-	Waypoints []Waypoint `gorm:"foreignKey:RouteID" json:"waypoints,omitempty"`
-}
-
-func (r *Route) BeforeCreate(tx *gorm.DB) (err error) {
-	if r.ID == uuid.Nil {
-		r.ID = uuid.New()
-	}
-	return
-}
+package domains
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Route struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	CreatorID uuid.UUID  `gorm:"type:uuid;column:creator_id;index" json:"creator_id"`
+	DriverID  *uuid.UUID `gorm:"type:uuid;column:driver_id;index" json:"driver_id"`
+
+	// TemplateID: Plantilla recurrente que generó esta ruta (nil si se creó a mano)
+	TemplateID *uuid.UUID `gorm:"type:uuid;column:template_id;index;uniqueIndex:idx_route_template_date" json:"template_id,omitempty"`
+	// TemplateDate: día (hora local) para el que la plantilla generó la ruta. Con TemplateID es único:
+	// una plantilla no genera dos rutas el mismo día aunque cambie la hora de salida o corran dos schedulers.
+	TemplateDate *time.Time `gorm:"type:date;uniqueIndex:idx_route_template_date" json:"-"`
+
+	Name                 string     `gorm:"not null" json:"name"`
+	Status               string     `gorm:"default:'draft';index" json:"status"`
+	ScheduledDate        *time.Time `gorm:"index" json:"scheduled_date"`
+	TotalDistanceKm      float64    `json:"total_distance_km"`
+	EstimatedDurationMin int        `json:"estimated_duration_min"`
+
+	// Version: control de concurrencia optimista (se expone como ETag)
+	Version int `gorm:"not null;default:1" json:"version"`
+
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relaciones
+	Creator   User       `gorm:"foreignKey:CreatorID" json:"creator,omitempty"`
+	Driver    *User      `gorm:"foreignKey:DriverID" json:"driver,omitempty"`
+	Waypoints []Waypoint `gorm:"foreignKey:RouteID" json:"waypoints,omitempty"`
+}
+
+func (r *Route) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	if r.Version == 0 {
+		r.Version = 1
+	}
+	return
+}
+
+func (r *Route) GetVersion() int  { return r.Version }
+func (r *Route) SetVersion(v int) { r.Version = v }
@@ -0,0 +1,44 @@
+package domains
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RouteMessage es un mensaje del chat conductor–despacho.
+// Cada ruta tiene un hilo general (WaypointID nil) y un hilo por parada, que queda en la ruta
+// aunque la parada se mueva a otra.
+type RouteMessage struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	RouteID    uuid.UUID  `gorm:"type:uuid;index;not null" json:"route_id"`
+	WaypointID *uuid.UUID `gorm:"type:uuid;index" json:"waypoint_id,omitempty"`
+	SenderID   uuid.UUID  `gorm:"type:uuid;not null" json:"sender_id"`
+
+	Body string `json:"body"`
+
+	// Imagen adjunta: se guarda el path del bucket y se firma al leer
+	AttachmentPath *string `json:"-"`
+	AttachmentURL  string  `gorm:"-" json:"attachment_url,omitempty"`
+
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+
+	// Relaciones
+	Sender   *User            `gorm:"foreignKey:SenderID" json:"sender,omitempty"`
+	Receipts []MessageReceipt `gorm:"foreignKey:MessageID" json:"read_by"`
+}
+
+func (m *RouteMessage) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return
+}
+
+// MessageReceipt es el acuse de lectura de un mensaje por un participante
+type MessageReceipt struct {
+	MessageID uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	ReadAt    time.Time `json:"read_at"`
+}
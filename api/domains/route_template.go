@@ -0,0 +1,67 @@
+package domains
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RouteTemplate define una ruta recurrente (ej: el reparto de todos los lunes)
+// a partir de la cual el scheduler genera Routes reales con anticipación.
+type RouteTemplate struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	CreatorID uuid.UUID `gorm:"type:uuid;column:creator_id;index" json:"creator_id"`
+
+	Name                 string  `gorm:"not null" json:"name"`
+	TotalDistanceKm      float64 `json:"total_distance_km"`
+	EstimatedDurationMin int     `json:"estimated_duration_min"`
+
+	// Recurrencia estilo iCal: DTSTART + RRULE (ej: "FREQ=WEEKLY;BYDAY=MO,WE,FR")
+	StartsAt time.Time `gorm:"not null" json:"starts_at"`
+	RRule    string    `gorm:"column:rrule;not null" json:"rrule"`
+
+	// Materialización
+	DaysAhead       int        `gorm:"default:7" json:"days_ahead"`          // Cuántos días hacia adelante se generan rutas
+	TargetStatus    string     `gorm:"default:'draft'" json:"target_status"` // draft o pending
+	DefaultDriverID *uuid.UUID `gorm:"type:uuid" json:"default_driver_id"`   // Conductor pre-asignado (opcional)
+	AutoOptimize    bool       `gorm:"default:false" json:"auto_optimize"`   // Optimizar el orden al generar
+	IsActive        bool       `json:"is_active"`                            // Pausar sin borrar
+	LastGeneratedAt *time.Time `json:"last_generated_at"`                    // Última corrida del scheduler
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relaciones
+	DefaultDriver *User                   `gorm:"foreignKey:DefaultDriverID" json:"default_driver,omitempty"`
+	Waypoints     []RouteTemplateWaypoint `gorm:"foreignKey:TemplateID" json:"waypoints,omitempty"`
+}
+
+func (t *RouteTemplate) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return
+}
+
+// RouteTemplateWaypoint es la parada "molde" que se copia a cada ruta generada
+type RouteTemplateWaypoint struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	TemplateID uuid.UUID `gorm:"type:uuid;column:template_id;index" json:"template_id"`
+
+	Address       string  `json:"address"`
+	Latitude      float64 `json:"latitude"`
+	Longitude     float64 `json:"longitude"`
+	SequenceOrder int     `json:"sequence_order"`
+	CustomerName  string  `json:"customer_name"`
+	CustomerEmail string  `json:"customer_email,omitempty"`
+	CustomerPhone string  `json:"customer_phone,omitempty"`
+	Notes         string  `json:"notes"`
+}
+
+func (w *RouteTemplateWaypoint) BeforeCreate(tx *gorm.DB) (err error) {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	return
+}
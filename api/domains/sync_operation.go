@@ -0,0 +1,32 @@
+package domains
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Resultado de una operación offline
+const (
+	SyncApplied  = "applied"  // Se aplicó
+	SyncSkipped  = "skipped"  // Ya estaba en ese estado (ej: entrega ya completada)
+	SyncConflict = "conflict" // Chocó con un cambio del servidor: gana el servidor
+	SyncRejected = "rejected" // Inválida o sin permiso
+)
+
+// SyncOperation guarda cada operación que el conductor generó sin conexión y su resultado.
+// El ID lo genera el cliente: si reenvía el lote, se devuelve el mismo resultado sin volver a aplicarla.
+type SyncOperation struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID     uuid.UUID `gorm:"type:uuid;index;not null" json:"-"`
+	Type       string    `gorm:"not null" json:"type"` // complete, fail, notes
+	WaypointID uuid.UUID `gorm:"type:uuid;index;not null" json:"waypoint_id"`
+	ClientTime time.Time `gorm:"not null" json:"client_time"`
+
+	Status  string `gorm:"not null" json:"status"` // Ver Sync*
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+	Version int    `json:"version,omitempty"` // Versión de la parada después de procesarla
+
+	CreatedAt time.Time `json:"processed_at"`
+}
@@ -0,0 +1,33 @@
+package domains
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TrackingLink es un link público (sin login) para que el cliente final siga su entrega.
+// El Token es el secreto de la URL; vence en ExpiresAt o al revocarse.
+type TrackingLink struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	WaypointID uuid.UUID `gorm:"type:uuid;index;not null" json:"waypoint_id"`
+	Token      string    `gorm:"uniqueIndex;not null" json:"token"`
+	CreatedBy  uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
+
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (t *TrackingLink) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return
+}
+
+// IsActive indica si el link todavía sirve
+func (t *TrackingLink) IsActive(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
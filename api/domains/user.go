@@ -1,31 +1,45 @@
-package domains
-
-import (
-	"time"
-
-	"github.com/google/uuid"
-)
-
-type User struct {
-	ID            uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
-	Email         string    `gorm:"uniqueIndex;not null" json:"email"`
-	FullName      string    `json:"full_name"`
-	AvatarURL     string    `json:"avatar_url"`
-	Role          string    `gorm:"default:'driver'" json:"role"`     // super_admin, admin, driver
-	Status        string    `gorm:"default:'inactive'" json:"status"` // active, inactive
-	EmailVerified bool      `json:"email_verified"`
-
-	// FleetCode: Código único que el Admin comparte
-	FleetCode *string `gorm:"uniqueIndex;default:null" json:"fleet_code,omitempty"`
-
-	// ManagerID: Quién es mi jefe (Self-Referential Foreign Key)
-	ManagerID *uuid.UUID `gorm:"type:uuid;default:null" json:"manager_id,omitempty"`
-
-	// Relaciones de GORM
-	Manager *User  `gorm:"foreignKey:ManagerID" json:"-"`       // Mi Jefe
-	Drivers []User `gorm:"foreignKey:ManagerID" json:"drivers"` // Mis Conductores
-
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
-	DeletedAt *time.Time `gorm:"index" json:"-"`
-}
+package domains
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type User struct {
+	ID            uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	Email         string    `gorm:"uniqueIndex;not null" json:"email"`
+	FullName      string    `json:"full_name"`
+	AvatarURL     string    `json:"avatar_url"`
+	Role          string    `gorm:"default:'driver'" json:"role"`     // super_admin, admin, driver
+	Status        string    `gorm:"default:'inactive'" json:"status"` // active, inactive
+	EmailVerified bool      `json:"email_verified"`
+
+	// FleetCode: Código único que el Admin comparte
+	FleetCode *string `gorm:"uniqueIndex;default:null" json:"fleet_code,omitempty"`
+
+	// ManagerID: Quién es mi jefe (Self-Referential Foreign Key)
+	ManagerID *uuid.UUID `gorm:"type:uuid;default:null" json:"manager_id,omitempty"`
+
+	// Datos operativos del conductor (usados por la asignación automática):
+	// base/domicilio de salida y capacidad del vehículo
+	HomeLatitude    *float64 `json:"home_latitude,omitempty"`
+	HomeLongitude   *float64 `json:"home_longitude,omitempty"`
+	VehicleCapacity int      `json:"vehicle_capacity"` // Máx. paradas por ruta (0 = sin límite)
+
+	// CalendarToken: secreto del feed iCal del conductor (nil = feed deshabilitado)
+	CalendarToken *string `gorm:"uniqueIndex;default:null" json:"-"`
+
+	// Relaciones de GORM
+	Manager *User  `gorm:"foreignKey:ManagerID" json:"-"`       // Mi Jefe
+	Drivers []User `gorm:"foreignKey:ManagerID" json:"drivers"` // Mis Conductores
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// PurgedAt: la papelera venció y se anonimizaron sus datos personales.
+	// La fila se conserva porque la auditoría y las rutas históricas la referencian.
+	PurgedAt *time.Time `json:"-"`
+}
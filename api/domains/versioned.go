@@ -0,0 +1,8 @@
+package domains
+
+// Versioned lo implementan los modelos con control de concurrencia optimista
+// (columna version que se incrementa en cada escritura)
+type Versioned interface {
+	GetVersion() int
+	SetVersion(v int)
+}
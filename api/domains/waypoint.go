@@ -1,26 +1,61 @@
-package domains
-
-import (
-	"time"
-
-	"github.com/google/uuid"
-)
-
-type Waypoint struct {
-	ID      uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
-	RouteID uuid.UUID `gorm:"type:uuid;column:route_id" json:"route_id"`
-
-	Address       string  `json:"address"`
-	Latitude      float64 `json:"latitude"`
-	Longitude     float64 `json:"longitude"`
-	SequenceOrder int     `json:"sequence_order"`
-	CustomerName  string  `json:"customer_name"`
-	Notes         string  `json:"notes"`
-
-	IsCompleted   bool       `gorm:"default:false" json:"is_completed"`
-	CompletedAt   *time.Time `json:"completed_at"`
-	ProofPhotoURL *string    `json:"proof_photo_url"`
-
-	// Relaciones
-	Route Route `gorm:"foreignKey:RouteID" json:"-"`
-}
+package domains
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Waypoint struct {
+	ID      uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	RouteID uuid.UUID `gorm:"type:uuid;column:route_id;index" json:"route_id"`
+
+	Address       string  `json:"address"`
+	Latitude      float64 `json:"latitude"`
+	Longitude     float64 `json:"longitude"`
+	SequenceOrder int     `json:"sequence_order"`
+	CustomerName  string  `json:"customer_name"`
+	CustomerEmail string  `json:"customer_email,omitempty"` // Contacto para avisos al cliente (opcional)
+	CustomerPhone string  `json:"customer_phone,omitempty"` // Formato E.164 (ej: +56912345678)
+	Notes         string  `json:"notes"`
+
+	IsCompleted   bool       `gorm:"default:false" json:"is_completed"`
+	CompletedAt   *time.Time `json:"completed_at"`
+	ProofPhotoURL *string    `json:"proof_photo_url"`
+
+	// Llegada / salida del conductor (manual o inferida del GPS) y tiempo de servicio
+	ArrivedAt       *time.Time `json:"arrived_at,omitempty"`
+	DepartedAt      *time.Time `json:"departed_at,omitempty"`
+	ArrivalSource   string     `json:"arrival_source,omitempty"`   // manual, inferred
+	DepartureSource string     `json:"departure_source,omitempty"` // manual, inferred
+	DwellSeconds    *int       `json:"dwell_seconds,omitempty"`
+
+	// Último intento fallido (nil si nunca falló o si ya se reprogramó/entregó)
+	FailedAt      *time.Time `gorm:"index" json:"failed_at,omitempty"`
+	FailureReason string     `json:"failure_reason,omitempty"`
+
+	// Version: control de concurrencia optimista (se expone como ETag)
+	Version int `gorm:"not null;default:1" json:"version"`
+
+	// Borrado lógico (se borran/restauran junto con su ruta)
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relaciones
+	Route    Route             `gorm:"foreignKey:RouteID" json:"-"`
+	Attempts []DeliveryAttempt `gorm:"foreignKey:WaypointID" json:"attempts,omitempty"`
+	Proof    *ProofOfDelivery  `gorm:"foreignKey:WaypointID" json:"proof,omitempty"` // Precargar con ActiveProof
+}
+
+func (w *Waypoint) BeforeCreate(tx *gorm.DB) (err error) {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	if w.Version == 0 {
+		w.Version = 1
+	}
+	return
+}
+
+func (w *Waypoint) GetVersion() int  { return w.Version }
+func (w *Waypoint) SetVersion(v int) { w.Version = v }
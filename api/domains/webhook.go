@@ -0,0 +1,128 @@
+package domains
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WebhookEndpoint es una URL del cliente (ej: su ERP) que recibe los eventos de una flota.
+// Secret firma cada envío (HMAC-SHA256); solo se muestra al crearlo o rotarlo.
+type WebhookEndpoint struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	AdminID     uuid.UUID `gorm:"type:uuid;index;not null" json:"admin_id"` // Flota dueña
+	URL         string    `gorm:"not null" json:"url"`
+	Description string    `json:"description"`
+	Secret      string    `gorm:"not null" json:"-"`
+
+	// Eventos suscritos (en la BD: separados por coma)
+	EventList string   `gorm:"column:events;not null" json:"-"`
+	Events    []string `gorm:"-" json:"events"`
+
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (w *WebhookEndpoint) BeforeCreate(tx *gorm.DB) (err error) {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	return
+}
+
+func (w *WebhookEndpoint) BeforeSave(tx *gorm.DB) (err error) {
+	w.EventList = strings.Join(w.Events, ",")
+	return
+}
+
+func (w *WebhookEndpoint) AfterFind(tx *gorm.DB) (err error) {
+	w.Events = []string{}
+	if w.EventList != "" {
+		w.Events = strings.Split(w.EventList, ",")
+	}
+	return
+}
+
+// Subscribed indica si el endpoint recibe ese tipo de evento
+func (w *WebhookEndpoint) Subscribed(eventType string) bool {
+	for _, e := range w.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// OutboxEvent es un evento de negocio escrito en la misma transacción que el cambio
+// (transactional outbox): si la transacción se revierte, el evento nunca existió.
+// El dispatcher lo reparte a los endpoints suscritos y lo marca como procesado.
+type OutboxEvent struct {
+	ID          uuid.UUID       `gorm:"type:uuid;primaryKey" json:"id"`
+	AdminID     uuid.UUID       `gorm:"type:uuid;not null" json:"admin_id"`
+	Type        string          `gorm:"not null" json:"type"`
+	Payload     json.RawMessage `gorm:"type:jsonb" json:"data"`
+	CreatedAt   time.Time       `json:"created_at"`
+	ProcessedAt *time.Time      `gorm:"index" json:"processed_at,omitempty"`
+}
+
+func (e *OutboxEvent) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return
+}
+
+// Estados de un envío
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead" // Agotó los reintentos (lista de "dead letters")
+)
+
+// WebhookDelivery es el envío de un evento a un endpoint (con sus reintentos)
+type WebhookDelivery struct {
+	ID         uuid.UUID       `gorm:"type:uuid;primaryKey" json:"id"`
+	EndpointID uuid.UUID       `gorm:"type:uuid;index;not null" json:"endpoint_id"`
+	EventID    uuid.UUID       `gorm:"type:uuid;not null" json:"event_id"`
+	EventType  string          `gorm:"not null" json:"event_type"`
+	Payload    json.RawMessage `gorm:"type:jsonb" json:"payload"` // Body exacto que se envía (y se firma)
+
+	Status         string     `gorm:"index;not null" json:"status"`
+	AttemptCount   int        `json:"attempt_count"`
+	NextAttemptAt  time.Time  `gorm:"index" json:"next_attempt_at"`
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+
+	Attempts []WebhookAttempt `gorm:"foreignKey:DeliveryID" json:"attempts,omitempty"`
+}
+
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) (err error) {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return
+}
+
+// WebhookAttempt es cada intento HTTP de un envío (registro de entregas)
+type WebhookAttempt struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	DeliveryID uuid.UUID `gorm:"type:uuid;index;not null" json:"-"`
+	Number     int       `json:"number"`
+	StatusCode int       `json:"status_code,omitempty"` // 0 si no hubo respuesta
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (a *WebhookAttempt) BeforeCreate(tx *gorm.DB) (err error) {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return
+}
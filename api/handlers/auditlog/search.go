@@ -0,0 +1,133 @@
+package auditlog
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 200
+)
+
+// SearchAuditLogs busca en la auditoría con filtros opcionales.
+// Super Admin ve todo; un Admin solo lo de su flota o lo que hizo él mismo.
+//
+// Query params: entity_type, entity_id, route_id, actor_id, action, request_id,
+// from, to (RFC3339), limit, before (created_at del último registro recibido)
+func SearchAuditLogs(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var user domains.User
+	if err := database.DB.Select("id, role").First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no encontrado"})
+		return
+	}
+
+	query := database.DB.Model(&domains.AuditLog{})
+	if user.Role != "super_admin" {
+		query = query.Where("owner_id = ? OR actor_id = ?", user.ID, user.ID)
+	}
+
+	// Filtros exactos
+	for _, field := range []string{"entity_type", "action", "request_id"} {
+		if value := c.Query(field); value != "" {
+			query = query.Where(field+" = ?", value)
+		}
+	}
+	for _, field := range []string{"entity_id", "route_id", "actor_id"} {
+		if value := c.Query(field); value != "" {
+			id, err := uuid.Parse(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Parámetro '" + field + "' inválido"})
+				return
+			}
+			query = query.Where(field+" = ?", id)
+		}
+	}
+
+	logs, msg := FetchPage(c, query)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	c.JSON(http.StatusOK, logs)
+}
+
+// FetchPage aplica rango de fechas y paginación (más reciente primero) y ejecuta la query.
+// Devuelve un mensaje no vacío si algún parámetro es inválido.
+func FetchPage(c *gin.Context, query *gorm.DB) (gin.H, string) {
+	if raw := c.Query("from"); raw != "" {
+		from, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, "Parámetro 'from' inválido (RFC3339)"
+		}
+		query = query.Where("created_at >= ?", from)
+	}
+	if raw := c.Query("to"); raw != "" {
+		to, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, "Parámetro 'to' inválido (RFC3339)"
+		}
+		query = query.Where("created_at <= ?", to)
+	}
+	if raw := c.Query("before"); raw != "" {
+		before, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return nil, "Parámetro 'before' inválido (RFC3339)"
+		}
+		query = query.Where("created_at < ?", before)
+	}
+
+	limit := defaultLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return nil, "Parámetro 'limit' inválido"
+		}
+		if n > maxLimit {
+			n = maxLimit
+		}
+		limit = n
+	}
+
+	var logs []domains.AuditLog
+	err := query.
+		Preload("Actor", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, full_name, email, role")
+		}).
+		Order("created_at DESC").
+		Limit(limit + 1).
+		Find(&logs).Error
+	if err != nil {
+		return nil, "Error consultando auditoría"
+	}
+
+	hasMore := len(logs) > limit
+	if hasMore {
+		logs = logs[:limit]
+	}
+
+	nextBefore := ""
+	if hasMore {
+		nextBefore = logs[len(logs)-1].CreatedAt.Format(time.RFC3339Nano)
+	}
+
+	return gin.H{
+		"data": logs,
+		"pagination": gin.H{
+			"limit":       limit,
+			"has_more":    hasMore,
+			"next_before": nextBefore,
+		},
+	}, ""
+}
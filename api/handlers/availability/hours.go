@@ -0,0 +1,125 @@
+package availability
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	availabilitySvc "github.com/tu-usuario/route-manager/api/services/availability"
+)
+
+type WorkingHoursDTO struct {
+	Weekday   int    `json:"weekday" binding:"min=0,max=6"` // 0 = domingo ... 6 = sábado
+	StartTime string `json:"start_time" binding:"required"` // "HH:MM"
+	EndTime   string `json:"end_time" binding:"required"`   // "HH:MM"
+}
+
+type SetWorkingHoursInput struct {
+	// Reemplaza el horario completo. Un array vacío = sin restricción horaria.
+	Hours []WorkingHoursDTO `json:"hours" binding:"dive"`
+}
+
+// GetMyAvailability devuelve el horario semanal y las ausencias del conductor logueado
+func GetMyAvailability(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	respondAvailability(c, userID)
+}
+
+// SetMyWorkingHours reemplaza el horario semanal del conductor logueado
+func SetMyWorkingHours(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	driverID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de usuario inválido"})
+		return
+	}
+
+	var input SetWorkingHoursInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	// 1. Validar tramos
+	hours := make([]domains.WorkingHours, 0, len(input.Hours))
+	for _, h := range input.Hours {
+		from, errFrom := availabilitySvc.ParseClock(h.StartTime)
+		to, errTo := availabilitySvc.ParseClock(h.EndTime)
+		if errFrom != nil || errTo != nil || from >= to {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Tramo horario inválido: " + h.StartTime + "-" + h.EndTime})
+			return
+		}
+		hours = append(hours, domains.WorkingHours{
+			ID:        uuid.New(),
+			DriverID:  driverID,
+			Weekday:   h.Weekday,
+			StartTime: h.StartTime,
+			EndTime:   h.EndTime,
+		})
+	}
+
+	// 2. Reemplazar en transacción
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("driver_id = ?", driverID).Delete(&domains.WorkingHours{}).Error; err != nil {
+			return err
+		}
+		if len(hours) == 0 {
+			return nil
+		}
+		return tx.Create(&hours).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error guardando horario"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Horario actualizado", "hours": hours})
+}
+
+// GetDriverAvailability: el jefe consulta la disponibilidad de uno de sus conductores
+func GetDriverAvailability(c *gin.Context) {
+	driverID := c.Param("id")
+	userID, _ := c.Get("userID")
+
+	var manager domains.User
+	if err := database.DB.Select("id, role").First(&manager, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no encontrado"})
+		return
+	}
+
+	var driver domains.User
+	if err := database.DB.Select("id, manager_id").First(&driver, "id = ?", driverID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conductor no encontrado"})
+		return
+	}
+
+	if manager.Role != "super_admin" && (driver.ManagerID == nil || *driver.ManagerID != manager.ID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "El conductor no pertenece a tu flota"})
+		return
+	}
+
+	respondAvailability(c, driver.ID)
+}
+
+func respondAvailability(c *gin.Context, driverID interface{}) {
+	var hours []domains.WorkingHours
+	if err := database.DB.Where("driver_id = ?", driverID).Order("weekday ASC, start_time ASC").Find(&hours).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo horario"})
+		return
+	}
+
+	var timeOffs []domains.TimeOff
+	if err := database.DB.Where("driver_id = ?", driverID).Order("start_date DESC").Find(&timeOffs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo ausencias"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"working_hours": hours,
+		"time_off":      timeOffs,
+	})
+}
@@ -0,0 +1,180 @@
+package availability
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+)
+
+var validTimeOffTypes = map[string]bool{"vacation": true, "sick": true, "personal": true}
+
+type RequestTimeOffInput struct {
+	Type      string `json:"type" binding:"required"`       // vacation, sick, personal
+	StartDate string `json:"start_date" binding:"required"` // YYYY-MM-DD
+	EndDate   string `json:"end_date" binding:"required"`   // YYYY-MM-DD (inclusive)
+	Reason    string `json:"reason"`
+}
+
+type ReviewTimeOffInput struct {
+	Status string `json:"status" binding:"required,oneof=approved rejected"`
+	Note   string `json:"note"`
+}
+
+// RequestTimeOff: el conductor solicita una ausencia (queda pendiente de su jefe)
+func RequestTimeOff(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	driverID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de usuario inválido"})
+		return
+	}
+
+	var input RequestTimeOffInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	if !validTimeOffTypes[input.Type] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tipo inválido (vacation, sick, personal)"})
+		return
+	}
+
+	start, errStart := time.Parse("2006-01-02", input.StartDate)
+	end, errEnd := time.Parse("2006-01-02", input.EndDate)
+	if errStart != nil || errEnd != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fechas inválidas (usa YYYY-MM-DD)"})
+		return
+	}
+	if end.Before(start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La fecha de fin no puede ser anterior a la de inicio"})
+		return
+	}
+
+	timeOff := domains.TimeOff{
+		ID:        uuid.New(),
+		DriverID:  driverID,
+		Type:      input.Type,
+		StartDate: start,
+		EndDate:   end,
+		Reason:    input.Reason,
+		Status:    "pending",
+	}
+
+	if err := database.DB.Create(&timeOff).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error registrando ausencia"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Solicitud enviada. Pendiente de aprobación.",
+		"time_off": timeOff,
+	})
+}
+
+// CancelTimeOff: el conductor retira una solicitud propia que aún no terminó
+func CancelTimeOff(c *gin.Context) {
+	timeOffID := c.Param("id")
+	userID, _ := c.Get("userID")
+
+	var timeOff domains.TimeOff
+	if err := database.DB.First(&timeOff, "id = ? AND driver_id = ?", timeOffID, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ausencia no encontrada"})
+		return
+	}
+
+	today := time.Now().Format("2006-01-02")
+	if timeOff.EndDate.Format("2006-01-02") < today {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No se puede cancelar una ausencia ya finalizada"})
+		return
+	}
+
+	if err := database.DB.Delete(&timeOff).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error cancelando ausencia"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Ausencia cancelada"})
+}
+
+// ListTimeOffRequests: el jefe ve las ausencias de sus conductores (?status=pending por defecto)
+func ListTimeOffRequests(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var manager domains.User
+	if err := database.DB.Select("id, role").First(&manager, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no encontrado"})
+		return
+	}
+
+	query := database.DB.Model(&domains.TimeOff{}).
+		Preload("Driver", func(db *gorm.DB) *gorm.DB { return db.Select("id, full_name, email") }).
+		Order("start_date ASC")
+
+	if manager.Role != "super_admin" {
+		query = query.Where("driver_id IN (?)",
+			database.DB.Model(&domains.User{}).Select("id").Where("manager_id = ?", manager.ID))
+	}
+
+	status := c.DefaultQuery("status", "pending")
+	if status != "all" {
+		query = query.Where("status = ?", status)
+	}
+
+	var requests []domains.TimeOff
+	if err := query.Find(&requests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error listando ausencias"})
+		return
+	}
+
+	c.JSON(http.StatusOK, requests)
+}
+
+// ReviewTimeOff: el jefe aprueba o rechaza la ausencia de uno de sus conductores
+func ReviewTimeOff(c *gin.Context) {
+	timeOffID := c.Param("id")
+	userID, _ := c.Get("userID")
+
+	var input ReviewTimeOffInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var manager domains.User
+	if err := database.DB.Select("id, role").First(&manager, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no encontrado"})
+		return
+	}
+
+	var timeOff domains.TimeOff
+	if err := database.DB.Preload("Driver").First(&timeOff, "id = ?", timeOffID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ausencia no encontrada"})
+		return
+	}
+
+	if manager.Role != "super_admin" &&
+		(timeOff.Driver == nil || timeOff.Driver.ManagerID == nil || *timeOff.Driver.ManagerID != manager.ID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "El conductor no pertenece a tu flota"})
+		return
+	}
+
+	now := time.Now()
+	timeOff.Status = input.Status
+	timeOff.ReviewNote = input.Note
+	timeOff.ReviewedBy = &manager.ID
+	timeOff.ReviewedAt = &now
+
+	if err := database.DB.Omit("Driver").Save(&timeOff).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error guardando revisión"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Ausencia " + input.Status, "time_off": timeOff})
+}
@@ -0,0 +1,54 @@
+package calendar
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	calendarSvc "github.com/tu-usuario/route-manager/api/services/calendar"
+)
+
+const (
+	feedPastDays  = 30  // Cuánto historial mostramos en el calendario
+	feedMaxEvents = 500 // Tope de eventos por feed
+)
+
+// DriverCalendarFeed sirve el .ics del conductor. Es PÚBLICO (los clientes de calendario
+// no mandan JWT): la autorización es el token secreto de la URL.
+func DriverCalendarFeed(c *gin.Context) {
+	driverID := c.Param("id")
+	token := c.Query("token")
+
+	// 1. Validar token (respuesta genérica para no revelar qué conductores existen)
+	var driver domains.User
+	if token == "" || database.DB.First(&driver, "id = ?", driverID).Error != nil ||
+		driver.CalendarToken == nil ||
+		subtle.ConstantTimeCompare([]byte(*driver.CalendarToken), []byte(token)) != 1 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Calendario no encontrado"})
+		return
+	}
+
+	// 2. Rutas asignadas (recientes y futuras)
+	var routesList []domains.Route
+	since := time.Now().AddDate(0, 0, -feedPastDays)
+	if err := database.DB.
+		Preload("Waypoints").
+		Where("driver_id = ? AND scheduled_date >= ?", driver.ID, since).
+		Order("scheduled_date ASC").
+		Limit(feedMaxEvents).
+		Find(&routesList).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generando calendario"})
+		return
+	}
+
+	// 3. Render (se genera en cada petición: siempre refleja el estado actual de las rutas)
+	body := calendarSvc.NewFeed().Build("Rutas - "+driver.FullName, routesList)
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Content-Disposition", `inline; filename="rutas.ics"`)
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(body))
+}
@@ -0,0 +1,107 @@
+package calendar
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/audit"
+	"github.com/tu-usuario/route-manager/api/utils"
+)
+
+// GetMyCalendar indica si el feed está activo y su URL de suscripción
+func GetMyCalendar(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var user domains.User
+	if err := database.DB.First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		return
+	}
+
+	if user.CalendarToken == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":  true,
+		"feed_url": feedURL(c, &user),
+	})
+}
+
+// RotateCalendarToken genera (o regenera) el token del feed. El anterior deja de funcionar.
+func RotateCalendarToken(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var user domains.User
+	if err := database.DB.First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		return
+	}
+
+	action := "calendar_token_create"
+	if user.CalendarToken != nil {
+		action = "calendar_token_rotate"
+	}
+	token := utils.GenerateSecureToken(32)
+
+	if err := saveCalendarToken(c, &user, &token, action); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generando token de calendario"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":  true,
+		"feed_url": feedURL(c, &user),
+	})
+}
+
+// RevokeCalendarToken desactiva el feed (los calendarios suscritos dejan de actualizarse)
+func RevokeCalendarToken(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var user domains.User
+	if err := database.DB.First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		return
+	}
+
+	if user.CalendarToken == nil {
+		c.JSON(http.StatusOK, gin.H{"message": "El calendario ya estaba desactivado"})
+		return
+	}
+
+	if err := saveCalendarToken(c, &user, nil, "calendar_token_revoke"); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error revocando token de calendario"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Calendario desactivado"})
+}
+
+// saveCalendarToken guarda el token y deja constancia en auditoría (sin el secreto)
+func saveCalendarToken(c *gin.Context, user *domains.User, token *string, action string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("calendar_token", token).Error; err != nil {
+			return err
+		}
+		user.CalendarToken = token
+		return audit.Record(tx, audit.FromContext(c), audit.UserEntry(action, nil, user))
+	})
+}
+
+// feedURL arma la URL pública del .ics a partir del host de la petición
+func feedURL(c *gin.Context, user *domains.User) string {
+	scheme := "https"
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	} else if c.Request.TLS == nil {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/api/v1/drivers/%s/calendar.ics?token=%s", scheme, c.Request.Host, user.ID, *user.CalendarToken)
+}
@@ -0,0 +1,143 @@
+package customernotify
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/fleet"
+	"github.com/tu-usuario/route-manager/api/services/notify"
+)
+
+type OptOutInput struct {
+	Channel string `json:"channel" binding:"required"`
+	Contact string `json:"contact" binding:"required"`
+}
+
+// ListNotifications es el registro de avisos de la flota (?status=, ?route_id=, ?waypoint_id=)
+func ListNotifications(c *gin.Context) {
+	adminID, ok := fleet.Resolve(c)
+	if !ok {
+		return
+	}
+
+	query := database.DB.Where("admin_id = ?", adminID).Order("created_at DESC").Limit(100)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if routeID := c.Query("route_id"); routeID != "" {
+		if _, err := uuid.Parse(routeID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "route_id inválido"})
+			return
+		}
+		query = query.Where("route_id = ?", routeID)
+	}
+	if waypointID := c.Query("waypoint_id"); waypointID != "" {
+		if _, err := uuid.Parse(waypointID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "waypoint_id inválido"})
+			return
+		}
+		query = query.Where("waypoint_id = ?", waypointID)
+	}
+
+	var notifications []domains.CustomerNotification
+	if err := query.Find(&notifications).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error listando avisos"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"notifications": notifications})
+}
+
+// ListOptOuts lista los contactos dados de baja en la flota
+func ListOptOuts(c *gin.Context) {
+	adminID, ok := fleet.Resolve(c)
+	if !ok {
+		return
+	}
+
+	var optOuts []domains.ContactOptOut
+	if err := database.DB.Where("admin_id = ?", adminID).Order("created_at DESC").Find(&optOuts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error listando bajas"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"opt_outs": optOuts})
+}
+
+// CreateOptOut da de baja un contacto a pedido del cliente (ej: lo pidió por teléfono)
+func CreateOptOut(c *gin.Context) {
+	adminID, ok := fleet.Resolve(c)
+	if !ok {
+		return
+	}
+
+	var input OptOutInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !notify.IsChannel(input.Channel) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Canal desconocido: " + input.Channel, "available_channels": notify.Channels})
+		return
+	}
+
+	optOut := domains.ContactOptOut{
+		AdminID: adminID,
+		Channel: input.Channel,
+		Contact: notify.NormalizeContact(input.Channel, input.Contact),
+		Source:  "admin",
+	}
+	if err := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&optOut).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error registrando baja"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Contacto dado de baja", "contact": optOut.Contact, "channel": optOut.Channel})
+}
+
+// DeleteOptOut reactiva los avisos para un contacto (solo si el cliente lo pidió)
+func DeleteOptOut(c *gin.Context) {
+	adminID, ok := fleet.Resolve(c)
+	if !ok {
+		return
+	}
+
+	result := database.DB.Where("id = ? AND admin_id = ?", c.Param("id"), adminID).Delete(&domains.ContactOptOut{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error eliminando baja"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Baja no encontrada"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "El contacto volverá a recibir avisos"})
+}
+
+// Unsubscribe es el endpoint PÚBLICO del link de baja incluido en cada aviso (autoriza el token)
+func Unsubscribe(c *gin.Context) {
+	var notification domains.CustomerNotification
+	if err := database.DB.First(&notification, "unsubscribe_token = ?", c.Param("token")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Link de baja no encontrado"})
+		return
+	}
+
+	optOut := domains.ContactOptOut{
+		AdminID: notification.AdminID,
+		Channel: notification.Channel,
+		Contact: notification.Recipient,
+		Source:  "link",
+	}
+	if err := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&optOut).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error registrando baja"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Listo: no volverás a recibir avisos de entrega por este medio"})
+}
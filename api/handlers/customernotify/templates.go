@@ -0,0 +1,152 @@
+package customernotify
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/fleet"
+	"github.com/tu-usuario/route-manager/api/services/notify"
+)
+
+type TemplateInput struct {
+	Subject  *string `json:"subject"`
+	Body     *string `json:"body"`
+	IsActive *bool   `json:"is_active"`
+}
+
+// TemplateView: plantilla efectiva de un evento y canal (personalizada o de fábrica)
+type TemplateView struct {
+	domains.NotificationTemplate
+	IsDefault bool `json:"is_default"`
+}
+
+// ListTemplates devuelve las plantillas efectivas de la flota para cada evento y canal
+func ListTemplates(c *gin.Context) {
+	adminID, ok := fleet.Resolve(c)
+	if !ok {
+		return
+	}
+
+	var custom []domains.NotificationTemplate
+	if err := database.DB.Where("admin_id = ?", adminID).Find(&custom).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error listando plantillas"})
+		return
+	}
+	byKey := map[string]domains.NotificationTemplate{}
+	for _, tpl := range custom {
+		byKey[tpl.Event+"/"+tpl.Channel] = tpl
+	}
+
+	views := make([]TemplateView, 0, len(notify.Events)*len(notify.Channels))
+	for _, event := range notify.Events {
+		for _, channel := range notify.Channels {
+			if tpl, found := byKey[event+"/"+channel]; found {
+				views = append(views, TemplateView{NotificationTemplate: tpl})
+				continue
+			}
+			views = append(views, TemplateView{NotificationTemplate: notify.DefaultTemplate(adminID, event, channel), IsDefault: true})
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"templates":    views,
+		"placeholders": notify.Placeholders,
+		"channels_enabled": gin.H{
+			domains.ChannelEmail: notify.Enabled(domains.ChannelEmail),
+			domains.ChannelSMS:   notify.Enabled(domains.ChannelSMS),
+		},
+	})
+}
+
+// UpsertTemplate personaliza el texto (o desactiva) el aviso de un evento y canal
+func UpsertTemplate(c *gin.Context) {
+	adminID, ok := fleet.Resolve(c)
+	if !ok {
+		return
+	}
+
+	event, channel, ok := templateKey(c)
+	if !ok {
+		return
+	}
+
+	var input TemplateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tpl, err := notify.TemplateFor(database.DB, adminID, event, channel)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo plantilla"})
+		return
+	}
+
+	if input.Subject != nil {
+		tpl.Subject = *input.Subject
+	}
+	if input.Body != nil {
+		tpl.Body = *input.Body
+	}
+	if input.IsActive != nil {
+		tpl.IsActive = *input.IsActive
+	}
+
+	if tpl.Body == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "body no puede estar vacío"})
+		return
+	}
+	if channel == domains.ChannelEmail && tpl.Subject == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "subject es obligatorio para email"})
+		return
+	}
+	if channel == domains.ChannelSMS {
+		tpl.Subject = ""
+	}
+
+	if err := database.DB.Save(&tpl).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error guardando plantilla"})
+		return
+	}
+
+	c.JSON(http.StatusOK, TemplateView{NotificationTemplate: tpl})
+}
+
+// ResetTemplate borra la personalización: vuelve a usarse la plantilla de fábrica
+func ResetTemplate(c *gin.Context) {
+	adminID, ok := fleet.Resolve(c)
+	if !ok {
+		return
+	}
+
+	event, channel, ok := templateKey(c)
+	if !ok {
+		return
+	}
+
+	err := database.DB.Where("admin_id = ? AND event = ? AND channel = ?", adminID, event, channel).
+		Delete(&domains.NotificationTemplate{}).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error restableciendo plantilla"})
+		return
+	}
+
+	c.JSON(http.StatusOK, TemplateView{NotificationTemplate: notify.DefaultTemplate(adminID, event, channel), IsDefault: true})
+}
+
+// templateKey valida :event y :channel de la URL. Si falla, ya respondió al cliente.
+func templateKey(c *gin.Context) (string, string, bool) {
+	event, channel := c.Param("event"), c.Param("channel")
+	if !notify.IsEvent(event) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Evento desconocido: " + event, "available_events": notify.Events})
+		return "", "", false
+	}
+	if !notify.IsChannel(channel) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Canal desconocido: " + channel, "available_channels": notify.Channels})
+		return "", "", false
+	}
+	return event, channel, true
+}
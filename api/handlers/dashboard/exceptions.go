@@ -0,0 +1,163 @@
+package dashboard
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/audit"
+	"github.com/tu-usuario/route-manager/api/services/geofence"
+)
+
+// GeofenceException es una entrega completada lejos de la parada (o sin GPS confiable)
+type GeofenceException struct {
+	ProofID    uuid.UUID  `json:"proof_id"`
+	WaypointID uuid.UUID  `json:"waypoint_id"`
+	RouteID    uuid.UUID  `json:"route_id"`
+	RouteName  string     `json:"route_name"`
+	DriverName string     `json:"driver_name"`
+	Address    string     `json:"address"`
+	Reason     string     `json:"reason"`
+	DistanceM  *float64   `json:"distance_m,omitempty"`
+	CapturedAt time.Time  `json:"captured_at"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+	ReviewNote string     `json:"review_note,omitempty"`
+}
+
+// exceptionsQuery arma la consulta de excepciones visible para el usuario
+func exceptionsQuery(user *domains.User) *gorm.DB {
+	query := database.DB.Table("proof_of_deliveries AS p").
+		Select(`p.id AS proof_id, p.waypoint_id, w.route_id, r.name AS route_name,
+			COALESCE(d.full_name, '') AS driver_name, w.address, p.geofence_reason AS reason,
+			p.distance_m, p.captured_at, p.reviewed_at, p.review_note`).
+		Joins("JOIN waypoints w ON w.id = p.waypoint_id AND w.deleted_at IS NULL").
+		Joins("JOIN routes r ON r.id = w.route_id AND r.deleted_at IS NULL").
+		Joins("LEFT JOIN users d ON d.id = r.driver_id").
+		Where("p.geofence_result = ? AND p.superseded_at IS NULL", geofence.Flagged)
+
+	if user.Role != "super_admin" {
+		query = query.Where("r.creator_id = ?", user.ID)
+	}
+	return query
+}
+
+// pendingExceptions devuelve las excepciones sin revisar más recientes (para el dashboard)
+func pendingExceptions(user *domains.User, limit int) ([]GeofenceException, int64) {
+	var total int64
+	exceptionsQuery(user).Where("p.reviewed_at IS NULL").Count(&total)
+
+	exceptions := []GeofenceException{}
+	exceptionsQuery(user).
+		Where("p.reviewed_at IS NULL").
+		Order("p.captured_at DESC").
+		Limit(limit).
+		Scan(&exceptions)
+
+	return exceptions, total
+}
+
+// ListExceptions lista las excepciones de geocerca (?include_reviewed=true para ver todas)
+func ListExceptions(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var currentUser domains.User
+	if err := database.DB.First(&currentUser, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no encontrado"})
+		return
+	}
+
+	query := exceptionsQuery(&currentUser)
+	if c.Query("include_reviewed") != "true" {
+		query = query.Where("p.reviewed_at IS NULL")
+	}
+
+	exceptions := []GeofenceException{}
+	if err := query.Order("p.captured_at DESC").Limit(200).Scan(&exceptions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo excepciones"})
+		return
+	}
+
+	c.JSON(http.StatusOK, exceptions)
+}
+
+type ResolveExceptionInput struct {
+	Note string `json:"note"`
+}
+
+// ResolveException marca una excepción como revisada por el Admin
+func ResolveException(c *gin.Context) {
+	proofID := c.Param("id")
+	userID, _ := c.Get("userID")
+
+	var input ResolveExceptionInput
+	if err := c.ShouldBindJSON(&input); err != nil && err.Error() != "EOF" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var currentUser domains.User
+	if err := database.DB.First(&currentUser, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no encontrado"})
+		return
+	}
+
+	var exception GeofenceException
+	if err := exceptionsQuery(&currentUser).Where("p.id = ?", proofID).Take(&exception).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Excepción no encontrada"})
+		return
+	}
+	if exception.ReviewedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La excepción ya fue revisada"})
+		return
+	}
+
+	var proof domains.ProofOfDelivery
+	if err := database.DB.First(&proof, "id = ?", exception.ProofID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Excepción no encontrada"})
+		return
+	}
+
+	var route domains.Route
+	database.DB.Select("id, creator_id").First(&route, "id = ?", exception.RouteID)
+
+	before := proof
+	now := time.Now()
+	proof.ReviewedAt = &now
+	proof.ReviewedBy = &currentUser.ID
+	proof.ReviewNote = input.Note
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&proof).Updates(map[string]interface{}{
+			"reviewed_at": proof.ReviewedAt,
+			"reviewed_by": proof.ReviewedBy,
+			"review_note": proof.ReviewNote,
+		}).Error; err != nil {
+			return err
+		}
+
+		routeID := exception.RouteID
+		ownerID := route.CreatorID
+		return audit.Record(tx, audit.FromContext(c), audit.Entry{
+			EntityType: audit.EntityWaypoint,
+			EntityID:   exception.WaypointID,
+			Action:     "geofence_review",
+			RouteID:    &routeID,
+			OwnerID:    &ownerID,
+			Before:     before,
+			After:      proof,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error resolviendo excepción"})
+		return
+	}
+
+	exception.ReviewedAt = proof.ReviewedAt
+	exception.ReviewNote = proof.ReviewNote
+	c.JSON(http.StatusOK, exception)
+}
@@ -0,0 +1,154 @@
+package dashboard
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+)
+
+type DriverServiceTime struct {
+	DriverID           uuid.UUID `json:"driver_id"`
+	DriverName         string    `json:"driver_name"`
+	StopsMeasured      int       `json:"stops_measured"`
+	AvgDwellSeconds    float64   `json:"avg_dwell_seconds"`
+	MedianDwellSeconds float64   `json:"median_dwell_seconds"`
+}
+
+type ServiceTimeStats struct {
+	From               time.Time `json:"from"`
+	To                 time.Time `json:"to"`
+	StopsMeasured      int       `json:"stops_measured"`
+	AvgDwellSeconds    float64   `json:"avg_dwell_seconds"`
+	MedianDwellSeconds float64   `json:"median_dwell_seconds"`
+
+	// Calibración: rutas completadas con llegada/salida medidas
+	RoutesMeasured      int      `json:"routes_measured"`
+	AvgActualMinutes    float64  `json:"avg_actual_duration_min"`
+	AvgEstimatedMinutes float64  `json:"avg_estimated_duration_min"`
+	AvgDeviationPct     *float64 `json:"avg_deviation_pct"` // >0: las rutas tardan más de lo estimado
+
+	Drivers []DriverServiceTime `json:"drivers"`
+}
+
+// GetServiceTimeStats resume tiempos de servicio de la flota (?from=&to= YYYY-MM-DD, defecto: últimos 30 días)
+func GetServiceTimeStats(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var currentUser domains.User
+	if err := database.DB.First(&currentUser, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no encontrado"})
+		return
+	}
+
+	to := time.Now()
+	from := to.AddDate(0, 0, -30)
+	if raw := c.Query("from"); raw != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", raw, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from debe ser YYYY-MM-DD"})
+			return
+		}
+		from = parsed
+	}
+	if raw := c.Query("to"); raw != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", raw, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to debe ser YYYY-MM-DD"})
+			return
+		}
+		to = parsed.AddDate(0, 0, 1) // Inclusivo
+	}
+
+	// scoped: paradas medidas (no borradas) dentro del rango, filtradas por flota
+	scoped := func() *gorm.DB {
+		query := database.DB.Table("waypoints w").
+			Joins("JOIN routes r ON r.id = w.route_id AND r.deleted_at IS NULL").
+			Where("w.deleted_at IS NULL AND w.dwell_seconds IS NOT NULL").
+			Where("w.arrived_at >= ? AND w.arrived_at < ?", from, to)
+		if currentUser.Role != "super_admin" {
+			query = query.Where("r.creator_id = ?", currentUser.ID)
+		}
+		return query
+	}
+
+	stats := ServiceTimeStats{From: from, To: to, Drivers: []DriverServiceTime{}}
+
+	// 1. Global
+	var overall struct {
+		Stops  int
+		Avg    float64
+		Median float64
+	}
+	if err := scoped().
+		Select(`COUNT(*) AS stops, COALESCE(AVG(w.dwell_seconds), 0) AS avg,
+			COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY w.dwell_seconds), 0) AS median`).
+		Scan(&overall).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error calculando estadísticas"})
+		return
+	}
+	stats.StopsMeasured = overall.Stops
+	stats.AvgDwellSeconds = overall.Avg
+	stats.MedianDwellSeconds = overall.Median
+
+	// 2. Por conductor
+	if err := scoped().
+		Joins("JOIN users d ON d.id = r.driver_id").
+		Select(`r.driver_id, d.full_name AS driver_name, COUNT(*) AS stops_measured,
+			AVG(w.dwell_seconds) AS avg_dwell_seconds,
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY w.dwell_seconds) AS median_dwell_seconds`).
+		Group("r.driver_id, d.full_name").
+		Order("avg_dwell_seconds DESC").
+		Scan(&stats.Drivers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error calculando estadísticas"})
+		return
+	}
+
+	// 3. Duración real vs estimada (rutas completadas)
+	var routeRows []struct {
+		EstimatedDurationMin int
+		ActualMin            float64
+	}
+	routeQuery := database.DB.Table("routes r").
+		Joins("JOIN waypoints w ON w.route_id = r.id AND w.deleted_at IS NULL").
+		Where("r.deleted_at IS NULL AND r.status = ?", "completed").
+		Where("r.scheduled_date >= ? AND r.scheduled_date < ?", from, to)
+	if currentUser.Role != "super_admin" {
+		routeQuery = routeQuery.Where("r.creator_id = ?", currentUser.ID)
+	}
+	if err := routeQuery.
+		Select(`r.estimated_duration_min, EXTRACT(EPOCH FROM MAX(w.departed_at) - MIN(w.arrived_at)) / 60 AS actual_min`).
+		Group("r.id, r.estimated_duration_min").
+		Having("MAX(w.departed_at) IS NOT NULL AND MIN(w.arrived_at) IS NOT NULL").
+		Scan(&routeRows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error calculando estadísticas"})
+		return
+	}
+
+	var sumActual, sumEstimated, sumDeviation float64
+	withEstimate := 0
+	for _, row := range routeRows {
+		sumActual += row.ActualMin
+		if row.EstimatedDurationMin > 0 {
+			sumEstimated += float64(row.EstimatedDurationMin)
+			sumDeviation += (row.ActualMin - float64(row.EstimatedDurationMin)) / float64(row.EstimatedDurationMin) * 100
+			withEstimate++
+		}
+	}
+	stats.RoutesMeasured = len(routeRows)
+	if len(routeRows) > 0 {
+		stats.AvgActualMinutes = sumActual / float64(len(routeRows))
+	}
+	if withEstimate > 0 {
+		stats.AvgEstimatedMinutes = sumEstimated / float64(withEstimate)
+		deviation := sumDeviation / float64(withEstimate)
+		stats.AvgDeviationPct = &deviation
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
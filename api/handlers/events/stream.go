@@ -0,0 +1,72 @@
+package events
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/realtime"
+)
+
+// heartbeatInterval mantiene viva la conexión a través de proxies que cortan conexiones ociosas
+const heartbeatInterval = 25 * time.Second
+
+// StreamEvents abre un canal Server-Sent Events con el progreso de las rutas que el usuario puede ver.
+// Opcional: ?route_id= para seguir una sola ruta.
+func StreamEvents(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var user domains.User
+	if err := database.DB.Select("id, role").First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no encontrado"})
+		return
+	}
+
+	var routeFilter *uuid.UUID
+	if raw := c.Query("route_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "route_id inválido"})
+			return
+		}
+		routeFilter = &id
+	}
+
+	sub := realtime.Subscribe(func(e realtime.Event) bool {
+		if routeFilter != nil && e.RouteID != *routeFilter {
+			return false
+		}
+		return realtime.CanSee(&user, e)
+	})
+	defer realtime.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Nginx: no bufferear
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.SSEvent("ready", gin.H{"user_id": user.ID, "role": user.Role})
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case e, ok := <-sub.C:
+			if !ok {
+				return false
+			}
+			c.SSEvent(e.Type, e)
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("ping", time.Now())
+			return true
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package fleet
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/services/audit"
+	fleetSvc "github.com/tu-usuario/route-manager/api/services/fleet"
+)
+
+// UpdateSettingsInput: solo se actualizan los campos enviados
+type UpdateSettingsInput struct {
+	GeofenceAcceptRadiusM   *int  `json:"geofence_accept_radius_m"`
+	GeofenceRejectRadiusM   *int  `json:"geofence_reject_radius_m"`
+	GeofenceMaxAccuracyM    *int  `json:"geofence_max_accuracy_m"`
+	GeofenceRequireLocation *bool `json:"geofence_require_location"`
+	CompletionUndoWindowMin *int  `json:"completion_undo_window_min"`
+	CustomerNotifyStopsAway *int  `json:"customer_notify_stops_away"`
+
+	RequirePreTripInspection *bool `json:"require_pre_trip_inspection"`
+}
+
+// GetSettings devuelve la política de la flota (Admin: la suya; Super Admin: ?admin_id=)
+func GetSettings(c *gin.Context) {
+	adminID, ok := fleetSvc.Resolve(c)
+	if !ok {
+		return
+	}
+
+	settings, err := fleetSvc.Settings(adminID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo configuración"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings modifica la política de la flota
+func UpdateSettings(c *gin.Context) {
+	var input UpdateSettingsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	adminID, ok := fleetSvc.Resolve(c)
+	if !ok {
+		return
+	}
+
+	settings, err := fleetSvc.Settings(adminID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo configuración"})
+		return
+	}
+	before := settings
+
+	if input.GeofenceAcceptRadiusM != nil {
+		settings.GeofenceAcceptRadiusM = *input.GeofenceAcceptRadiusM
+	}
+	if input.GeofenceRejectRadiusM != nil {
+		settings.GeofenceRejectRadiusM = *input.GeofenceRejectRadiusM
+	}
+	if input.GeofenceMaxAccuracyM != nil {
+		settings.GeofenceMaxAccuracyM = *input.GeofenceMaxAccuracyM
+	}
+	if input.GeofenceRequireLocation != nil {
+		settings.GeofenceRequireLocation = *input.GeofenceRequireLocation
+	}
+	if input.CompletionUndoWindowMin != nil {
+		settings.CompletionUndoWindowMin = *input.CompletionUndoWindowMin
+	}
+	if input.CustomerNotifyStopsAway != nil {
+		settings.CustomerNotifyStopsAway = *input.CustomerNotifyStopsAway
+	}
+	if input.RequirePreTripInspection != nil {
+		settings.RequirePreTripInspection = *input.RequirePreTripInspection
+	}
+
+	// Validaciones de coherencia
+	if settings.GeofenceAcceptRadiusM < 10 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "geofence_accept_radius_m debe ser al menos 10"})
+		return
+	}
+	if settings.GeofenceRejectRadiusM < 0 || settings.GeofenceMaxAccuracyM < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Los radios no pueden ser negativos"})
+		return
+	}
+	if settings.CompletionUndoWindowMin < 1 || settings.CompletionUndoWindowMin > 60 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "completion_undo_window_min debe estar entre 1 y 60"})
+		return
+	}
+	if settings.CustomerNotifyStopsAway < 0 || settings.CustomerNotifyStopsAway > 20 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "customer_notify_stops_away debe estar entre 0 y 20 (0 = no avisar)"})
+		return
+	}
+	if settings.GeofenceRejectRadiusM > 0 && settings.GeofenceRejectRadiusM < settings.GeofenceAcceptRadiusM {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "geofence_reject_radius_m debe ser mayor que el radio de aceptación (o 0 para no rechazar)"})
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&settings).Error; err != nil {
+			return err
+		}
+		ownerID := adminID
+		return audit.Record(tx, audit.FromContext(c), audit.Entry{
+			EntityType: audit.EntityFleetSettings,
+			EntityID:   adminID,
+			Action:     "update",
+			OwnerID:    &ownerID,
+			Before:     before,
+			After:      settings,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error guardando configuración"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
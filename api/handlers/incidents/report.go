@@ -0,0 +1,252 @@
+package incidents
+
+import (
+	"fmt"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/inbox"
+	"github.com/tu-usuario/route-manager/api/services/realtime"
+	"github.com/tu-usuario/route-manager/api/services/storage"
+)
+
+const (
+	maxIncidentPhotos = 5
+	maxPhotoSize      = 10 << 20 // 10 MB
+)
+
+var (
+	categories = map[string]bool{"accident": true, "breakdown": true, "road_closure": true, "damaged_goods": true, "other": true}
+	severities = map[string]bool{"low": true, "medium": true, "high": true, "critical": true}
+)
+
+// ReportIncident: el conductor asignado reporta un problema (multipart).
+// Campos: category, severity, description, waypoint_id, latitude, longitude, accuracy,
+// occurred_at (RFC3339) y hasta 5 fotos en "photos".
+func ReportIncident(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	now := time.Now()
+
+	// 1. Ruta y permiso: solo su conductor reporta desde la calle
+	var route domains.Route
+	if err := database.DB.First(&route, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ruta no encontrada"})
+		return
+	}
+	if route.DriverID == nil || route.DriverID.String() != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Solo el conductor asignado puede reportar incidentes en esta ruta"})
+		return
+	}
+
+	// 2. Datos del incidente
+	incident := domains.Incident{
+		RouteID:     route.ID,
+		AdminID:     route.CreatorID,
+		ReporterID:  *route.DriverID,
+		Category:    c.PostForm("category"),
+		Severity:    c.PostForm("severity"),
+		Description: strings.TrimSpace(c.PostForm("description")),
+		OccurredAt:  now,
+		Status:      domains.IncidentOpen,
+	}
+	if !categories[incident.Category] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "category debe ser accident, breakdown, road_closure, damaged_goods u other"})
+		return
+	}
+	if incident.Severity == "" {
+		incident.Severity = "medium"
+	}
+	if !severities[incident.Severity] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "severity debe ser low, medium, high o critical"})
+		return
+	}
+	if incident.Description == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "description es obligatoria"})
+		return
+	}
+
+	if raw := c.PostForm("waypoint_id"); raw != "" {
+		var wp domains.Waypoint
+		if err := database.DB.Select("id").First(&wp, "id = ? AND route_id = ?", raw, route.ID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "La parada no pertenece a esta ruta"})
+			return
+		}
+		incident.WaypointID = &wp.ID
+	}
+
+	if raw := c.PostForm("occurred_at"); raw != "" {
+		occurredAt, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "occurred_at debe ser RFC3339"})
+			return
+		}
+		if occurredAt.After(now.Add(5 * time.Minute)) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "occurred_at no puede estar en el futuro"})
+			return
+		}
+		incident.OccurredAt = occurredAt
+	}
+
+	if !parseLocation(c, &incident) {
+		return
+	}
+
+	// 3. Fotos (recién ahora que el resto es válido)
+	var photoFiles []*multipart.FileHeader
+	if form, err := c.MultipartForm(); err == nil {
+		photoFiles = form.File["photos"]
+	}
+	if len(photoFiles) > maxIncidentPhotos {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Máximo %d fotos por incidente", maxIncidentPhotos)})
+		return
+	}
+	// Se validan todas antes de subir la primera, para no dejar archivos sueltos en el bucket
+	for _, fh := range photoFiles {
+		if fh.Size > maxPhotoSize || !strings.HasPrefix(fh.Header.Get("Content-Type"), "image/") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("\"%s\": la foto debe ser una imagen de hasta 10 MB", fh.Filename)})
+			return
+		}
+	}
+	svc := storage.NewService()
+	for i, fh := range photoFiles {
+		path, ok := uploadPhoto(c, svc, fh)
+		if !ok {
+			discardPhotos(svc, &incident)
+			return
+		}
+		incident.Photos = append(incident.Photos, domains.IncidentPhoto{URL: path, Position: i + 1})
+	}
+
+	// 4. Guardar + avisar al Admin de la ruta
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&incident).Error; err != nil {
+			return err
+		}
+		return inbox.NotifyIncidentReported(tx, &incident, &route)
+	})
+	if err != nil {
+		discardPhotos(svc, &incident)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error guardando incidente"})
+		return
+	}
+
+	realtime.Publish(realtime.RouteEvent(realtime.IncidentReported, &route, gin.H{
+		"incident_id": incident.ID,
+		"category":    incident.Category,
+		"severity":    incident.Severity,
+		"waypoint_id": incident.WaypointID,
+	}))
+
+	signPhotos(svc, &incident)
+	c.JSON(http.StatusCreated, incident)
+}
+
+// parseLocation lee latitude/longitude (van juntos) y accuracy. Si falla, ya respondió al cliente.
+func parseLocation(c *gin.Context, incident *domains.Incident) bool {
+	rawLat, rawLng := c.PostForm("latitude"), c.PostForm("longitude")
+	if (rawLat == "") != (rawLng == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "latitude y longitude van juntos"})
+		return false
+	}
+	if rawLat != "" {
+		lat, errLat := strconv.ParseFloat(rawLat, 64)
+		lng, errLng := strconv.ParseFloat(rawLng, 64)
+		if errLat != nil || errLng != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Coordenadas inválidas"})
+			return false
+		}
+		incident.Latitude, incident.Longitude = &lat, &lng
+	}
+
+	if raw := c.PostForm("accuracy"); raw != "" {
+		accuracy, err := strconv.ParseFloat(raw, 64)
+		if err != nil || accuracy < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "accuracy inválida"})
+			return false
+		}
+		incident.AccuracyM = &accuracy
+	}
+	return true
+}
+
+// uploadPhoto sube una foto y devuelve su path. Si falla, ya respondió al cliente.
+func uploadPhoto(c *gin.Context, svc *storage.Service, fileHeader *multipart.FileHeader) (string, bool) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error abriendo archivo"})
+		return "", false
+	}
+	defer file.Close()
+
+	path, err := svc.UploadFile(file, fileHeader.Filename, fileHeader.Header.Get("Content-Type"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error subiendo foto: " + err.Error()})
+		return "", false
+	}
+	return path, true
+}
+
+// discardPhotos borra del bucket las fotos ya subidas de un incidente que no se guardó
+func discardPhotos(svc *storage.Service, incident *domains.Incident) {
+	paths := make([]string, 0, len(incident.Photos))
+	for _, photo := range incident.Photos {
+		paths = append(paths, photo.URL)
+	}
+	if err := svc.DeleteFiles(paths...); err != nil {
+		log.Printf("Error borrando fotos de incidente descartado (ruta %s): %v", incident.RouteID, err)
+	}
+}
+
+// signPhotos reemplaza los paths por URLs firmadas para responder al front
+func signPhotos(svc *storage.Service, incident *domains.Incident) {
+	for i := range incident.Photos {
+		if signedURL, err := svc.GetSignedURL(incident.Photos[i].URL); err == nil {
+			incident.Photos[i].URL = signedURL
+		} else {
+			log.Printf("Error firmando foto de incidente %s: %v", incident.ID, err)
+		}
+	}
+}
+
+// canManageIncident: el Super Admin gestiona todo, el Admin los incidentes de sus rutas
+func canManageIncident(user *domains.User, incident *domains.Incident) bool {
+	if user.Role == "super_admin" {
+		return true
+	}
+	return user.Role == "admin" && incident.AdminID == user.ID
+}
+
+// currentUser carga el usuario logueado. Si falla, ya respondió al cliente.
+func currentUser(c *gin.Context) (*domains.User, bool) {
+	userID, _ := c.Get("userID")
+
+	var user domains.User
+	if err := database.DB.Select("id, role").First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no encontrado"})
+		return nil, false
+	}
+	return &user, true
+}
+
+// parseUUIDQuery valida un filtro UUID opcional. Si falla, ya respondió al cliente.
+func parseUUIDQuery(c *gin.Context, name string) (string, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return "", true
+	}
+	if _, err := uuid.Parse(raw); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parámetro '" + name + "' inválido"})
+		return "", false
+	}
+	return raw, true
+}
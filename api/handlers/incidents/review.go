@@ -0,0 +1,310 @@
+package incidents
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/inbox"
+	"github.com/tu-usuario/route-manager/api/services/realtime"
+	"github.com/tu-usuario/route-manager/api/services/storage"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 200
+)
+
+// listCursor apunta al último incidente entregado (created_at + ID como desempate),
+// con el mismo formato que el cursor del listado de rutas
+type listCursor struct {
+	Sort  string    `json:"s"`
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+type ResolveIncidentInput struct {
+	ResolutionNote string `json:"resolution_note" binding:"required,max=2000"`
+}
+
+// ListIncidents lista incidentes, el más nuevo primero.
+// Super Admin ve todo, Admin los de sus rutas, Driver los que reportó.
+// Query params: status, severity, category, route_id, limit, cursor (next_cursor de la página anterior)
+func ListIncidents(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	query := database.DB.Model(&domains.Incident{})
+	switch user.Role {
+	case "super_admin":
+	case "admin":
+		query = query.Where("admin_id = ?", user.ID)
+	default:
+		query = query.Where("reporter_id = ?", user.ID)
+	}
+
+	for _, field := range []string{"status", "severity", "category"} {
+		if value := c.Query(field); value != "" {
+			query = query.Where(field+" = ?", value)
+		}
+	}
+	routeID, ok := parseUUIDQuery(c, "route_id")
+	if !ok {
+		return
+	}
+	if routeID != "" {
+		query = query.Where("route_id = ?", routeID)
+	}
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, createdAt, err := decodeCursor(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cursor inválido"})
+			return
+		}
+		query = query.Where("(created_at < ?) OR (created_at = ? AND id < ?)", createdAt, createdAt, cursor.ID)
+	}
+
+	limit := defaultLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parámetro 'limit' inválido"})
+			return
+		}
+		if n > maxLimit {
+			n = maxLimit
+		}
+		limit = n
+	}
+
+	var items []domains.Incident
+	err := query.
+		Preload("Reporter", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, full_name, avatar_url, role")
+		}).
+		Preload("Photos", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		Order("created_at DESC, id DESC").
+		Limit(limit + 1).
+		Find(&items).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error listando incidentes"})
+		return
+	}
+
+	hasMore := len(items) > limit
+	if hasMore {
+		items = items[:limit]
+	}
+	nextCursor := ""
+	if hasMore && len(items) > 0 {
+		last := items[len(items)-1]
+		nextCursor = encodeCursor(last.ID, last.CreatedAt)
+	}
+
+	svc := storage.NewService()
+	for i := range items {
+		signPhotos(svc, &items[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": items,
+		"pagination": gin.H{
+			"limit":       limit,
+			"has_more":    hasMore,
+			"next_cursor": nextCursor,
+		},
+	})
+}
+
+// GetIncident devuelve un incidente con sus fotos firmadas
+func GetIncident(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var incident domains.Incident
+	err := database.DB.
+		Preload("Reporter", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, full_name, avatar_url, role")
+		}).
+		Preload("Photos", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		First(&incident, "id = ?", c.Param("id")).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Incidente no encontrado"})
+		return
+	}
+	if !canManageIncident(user, &incident) && incident.ReporterID != user.ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "No tienes acceso a este incidente"})
+		return
+	}
+
+	signPhotos(storage.NewService(), &incident)
+	c.JSON(http.StatusOK, incident)
+}
+
+// AcknowledgeIncident: el Admin toma el incidente (open → acknowledged)
+func AcknowledgeIncident(c *gin.Context) {
+	incident, user, ok := loadManagedIncident(c)
+	if !ok {
+		return
+	}
+	if incident.Status != domains.IncidentOpen {
+		c.JSON(http.StatusConflict, gin.H{"error": "Solo se puede tomar un incidente abierto"})
+		return
+	}
+
+	now := time.Now()
+	res := database.DB.Model(&domains.Incident{}).
+		Where("id = ? AND status = ?", incident.ID, domains.IncidentOpen).
+		Updates(map[string]interface{}{
+			"status":          domains.IncidentAcknowledged,
+			"acknowledged_at": now,
+			"acknowledged_by": user.ID,
+		})
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error actualizando incidente"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "El incidente cambió de estado, recarga"})
+		return
+	}
+
+	incident.Status = domains.IncidentAcknowledged
+	incident.AcknowledgedAt = &now
+	incident.AcknowledgedBy = &user.ID
+	publishUpdate(incident)
+
+	c.JSON(http.StatusOK, incident)
+}
+
+// ResolveIncident cierra el incidente con una nota (open/acknowledged → resolved) y avisa al conductor
+func ResolveIncident(c *gin.Context) {
+	var input ResolveIncidentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	note := strings.TrimSpace(input.ResolutionNote)
+	if note == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "resolution_note es obligatoria"})
+		return
+	}
+
+	incident, user, ok := loadManagedIncident(c)
+	if !ok {
+		return
+	}
+	if incident.Status == domains.IncidentResolved {
+		c.JSON(http.StatusConflict, gin.H{"error": "El incidente ya está resuelto"})
+		return
+	}
+
+	now := time.Now()
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&domains.Incident{}).
+			Where("id = ? AND status <> ?", incident.ID, domains.IncidentResolved).
+			Updates(map[string]interface{}{
+				"status":          domains.IncidentResolved,
+				"resolved_at":     now,
+				"resolved_by":     user.ID,
+				"resolution_note": note,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		incident.Status = domains.IncidentResolved
+		incident.ResolvedAt = &now
+		incident.ResolvedBy = &user.ID
+		incident.ResolutionNote = note
+		return inbox.NotifyIncidentResolved(tx, incident)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusConflict, gin.H{"error": "El incidente ya está resuelto"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error resolviendo incidente"})
+		return
+	}
+
+	publishUpdate(incident)
+	c.JSON(http.StatusOK, incident)
+}
+
+// loadManagedIncident carga el incidente de la URL y valida que el usuario lo gestione.
+// Si falla, ya respondió al cliente.
+func loadManagedIncident(c *gin.Context) (*domains.Incident, *domains.User, bool) {
+	user, ok := currentUser(c)
+	if !ok {
+		return nil, nil, false
+	}
+
+	var incident domains.Incident
+	if err := database.DB.First(&incident, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Incidente no encontrado"})
+		return nil, nil, false
+	}
+	if !canManageIncident(user, &incident) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Solo el Admin de la ruta puede gestionar este incidente"})
+		return nil, nil, false
+	}
+	return &incident, user, true
+}
+
+// publishUpdate avisa por SSE a quienes siguen la ruta (el conductor ve el cambio de estado)
+func publishUpdate(incident *domains.Incident) {
+	var route domains.Route
+	if err := database.DB.First(&route, "id = ?", incident.RouteID).Error; err != nil {
+		return
+	}
+	realtime.Publish(realtime.RouteEvent(realtime.IncidentUpdated, &route, gin.H{
+		"incident_id": incident.ID,
+		"status":      incident.Status,
+	}))
+}
+
+func encodeCursor(id uuid.UUID, createdAt time.Time) string {
+	raw, _ := json.Marshal(listCursor{Sort: "created_at", Value: createdAt.Format(time.RFC3339Nano), ID: id})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(raw string) (*listCursor, time.Time, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var cursor listCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, time.Time{}, err
+	}
+	if cursor.Sort != "created_at" {
+		return nil, time.Time{}, errors.New("cursor de otro orden")
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, cursor.Value)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return &cursor, createdAt, nil
+}
@@ -0,0 +1,265 @@
+package inspections
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/fleet"
+)
+
+// ChecklistItemDTO: un punto del checklist, en el orden en que se muestra
+type ChecklistItemDTO struct {
+	Label    string   `json:"label" binding:"required"`
+	Type     string   `json:"type" binding:"required,oneof=boolean text photo number"`
+	Required bool     `json:"required"`
+	Critical bool     `json:"critical"` // Solo boolean y number pueden fallar
+	MinValue *float64 `json:"min_value"`
+	MaxValue *float64 `json:"max_value"`
+}
+
+type CreateChecklistInput struct {
+	Name     string             `json:"name" binding:"required"`
+	IsActive bool               `json:"is_active"`
+	Items    []ChecklistItemDTO `json:"items" binding:"required,min=1,dive"`
+}
+
+// UpdateChecklistInput: todos los campos son opcionales.
+// Si viene "items", reemplaza por completo los ítems (los envíos anteriores conservan su copia).
+type UpdateChecklistInput struct {
+	Name     string             `json:"name"`
+	IsActive *bool              `json:"is_active"`
+	Items    []ChecklistItemDTO `json:"items" binding:"omitempty,min=1,dive"`
+}
+
+// ListChecklists lista los checklists de la flota (Admin: la suya; Super Admin: ?admin_id=)
+func ListChecklists(c *gin.Context) {
+	adminID, ok := fleet.Resolve(c)
+	if !ok {
+		return
+	}
+
+	var templates []domains.ChecklistTemplate
+	err := database.DB.
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		Where("admin_id = ?", adminID).
+		Order("created_at DESC").
+		Find(&templates).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error listando checklists"})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// CreateChecklist crea un checklist. Si se crea activo, desactiva el que estaba en uso.
+func CreateChecklist(c *gin.Context) {
+	var input CreateChecklistInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	adminID, ok := fleet.Resolve(c)
+	if !ok {
+		return
+	}
+
+	items, msg := buildItems(input.Items)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	template := domains.ChecklistTemplate{
+		ID:       uuid.New(),
+		AdminID:  adminID,
+		Name:     strings.TrimSpace(input.Name),
+		IsActive: input.IsActive,
+		Items:    items,
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if template.IsActive {
+			if err := deactivateOthers(tx, &template); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&template).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creando checklist"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// GetChecklist devuelve el detalle de un checklist
+func GetChecklist(c *gin.Context) {
+	template, ok := findOwnedChecklist(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// UpdateChecklist modifica nombre, estado o ítems del checklist
+func UpdateChecklist(c *gin.Context) {
+	var input UpdateChecklistInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, ok := findOwnedChecklist(c)
+	if !ok {
+		return
+	}
+
+	if name := strings.TrimSpace(input.Name); name != "" {
+		template.Name = name
+	}
+	if input.IsActive != nil {
+		template.IsActive = *input.IsActive
+	}
+
+	var items []domains.ChecklistItem
+	if input.Items != nil {
+		var msg string
+		if items, msg = buildItems(input.Items); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if template.IsActive {
+			if err := deactivateOthers(tx, template); err != nil {
+				return err
+			}
+		}
+		if input.Items != nil {
+			if err := tx.Where("template_id = ?", template.ID).Delete(&domains.ChecklistItem{}).Error; err != nil {
+				return err
+			}
+			for i := range items {
+				items[i].TemplateID = template.ID
+			}
+			if err := tx.Create(&items).Error; err != nil {
+				return err
+			}
+			template.Items = items
+		}
+
+		// Omit: los ítems ya se gestionaron arriba
+		return tx.Omit("Items").Save(template).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error actualizando checklist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// DeleteChecklist elimina el checklist y sus ítems.
+// Los envíos ya hechos se conservan (guardan su propia copia de cada ítem).
+func DeleteChecklist(c *gin.Context) {
+	template, ok := findOwnedChecklist(c)
+	if !ok {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("template_id = ?", template.ID).Delete(&domains.ChecklistItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&domains.ChecklistTemplate{}, "id = ?", template.ID).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error eliminando checklist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Checklist eliminado correctamente"})
+}
+
+// buildItems valida los ítems y los numera. Devuelve un mensaje no vacío si hay un error.
+func buildItems(dtos []ChecklistItemDTO) ([]domains.ChecklistItem, string) {
+	items := make([]domains.ChecklistItem, 0, len(dtos))
+	for i, dto := range dtos {
+		label := strings.TrimSpace(dto.Label)
+		if label == "" {
+			return nil, fmt.Sprintf("El ítem %d no tiene etiqueta", i+1)
+		}
+		if dto.Critical && dto.Type != domains.ChecklistItemBoolean && dto.Type != domains.ChecklistItemNumber {
+			return nil, fmt.Sprintf("\"%s\": solo los ítems boolean y number pueden ser críticos", label)
+		}
+		if dto.Type != domains.ChecklistItemNumber && (dto.MinValue != nil || dto.MaxValue != nil) {
+			return nil, fmt.Sprintf("\"%s\": min_value y max_value solo aplican a ítems number", label)
+		}
+		if dto.MinValue != nil && dto.MaxValue != nil && *dto.MinValue > *dto.MaxValue {
+			return nil, fmt.Sprintf("\"%s\": min_value no puede ser mayor que max_value", label)
+		}
+
+		items = append(items, domains.ChecklistItem{
+			ID:       uuid.New(),
+			Position: i + 1,
+			Label:    label,
+			Type:     dto.Type,
+			Required: dto.Required || dto.Critical, // Un ítem crítico siempre se responde
+			Critical: dto.Critical,
+			MinValue: dto.MinValue,
+			MaxValue: dto.MaxValue,
+		})
+	}
+	return items, ""
+}
+
+// deactivateOthers deja a template como único checklist activo de su flota
+func deactivateOthers(tx *gorm.DB, template *domains.ChecklistTemplate) error {
+	return tx.Model(&domains.ChecklistTemplate{}).
+		Where("admin_id = ? AND id <> ? AND is_active = ?", template.AdminID, template.ID, true).
+		Update("is_active", false).Error
+}
+
+// findOwnedChecklist busca el checklist de :id con sus ítems y verifica que sea de la
+// flota del usuario (o que sea super_admin). Si falla, ya respondió al cliente.
+func findOwnedChecklist(c *gin.Context) (*domains.ChecklistTemplate, bool) {
+	userID, _ := c.Get("userID")
+
+	var template domains.ChecklistTemplate
+	err := database.DB.
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		First(&template, "id = ?", c.Param("id")).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Checklist no encontrado"})
+		return nil, false
+	}
+
+	var user domains.User
+	if err := database.DB.Select("id, role").First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario inválido"})
+		return nil, false
+	}
+
+	if user.Role != "super_admin" && template.AdminID != user.ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "No tienes permiso sobre este checklist"})
+		return nil, false
+	}
+
+	return &template, true
+}
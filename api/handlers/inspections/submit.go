@@ -0,0 +1,292 @@
+package inspections
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/fleet"
+	"github.com/tu-usuario/route-manager/api/services/inbox"
+	"github.com/tu-usuario/route-manager/api/services/inspection"
+	"github.com/tu-usuario/route-manager/api/services/storage"
+)
+
+const maxPhotoSize = 10 << 20 // 10 MB
+
+// AnswerInput: respuesta a un ítem del checklist activo.
+// Las fotos van como archivo multipart en "photo_<item_id>".
+type AnswerInput struct {
+	ItemID      uuid.UUID `json:"item_id"`
+	BoolValue   *bool     `json:"bool_value"`
+	TextValue   string    `json:"text_value"`
+	NumberValue *float64  `json:"number_value"`
+}
+
+type SubmitInspectionInput struct {
+	Answers []AnswerInput `json:"answers"`
+}
+
+// GetRouteInspection devuelve lo que necesita la app antes de salir:
+// si la flota lo exige, el checklist activo y los envíos de la ruta (el más nuevo primero)
+func GetRouteInspection(c *gin.Context) {
+	route, ok := loadRoute(c, false)
+	if !ok {
+		return
+	}
+
+	policy, err := fleet.Settings(route.CreatorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo política de la flota"})
+		return
+	}
+	template, err := inspection.ActiveTemplate(database.DB, route.CreatorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo checklist"})
+		return
+	}
+
+	var submissions []domains.InspectionSubmission
+	err = database.DB.Preload("Answers").
+		Where("route_id = ?", route.ID).
+		Order("created_at DESC").
+		Find(&submissions).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error listando inspecciones"})
+		return
+	}
+	signPhotos(submissions)
+
+	code, reason, err := inspection.StartBlocker(route)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error evaluando inspección"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"required":     policy.RequirePreTripInspection,
+		"can_start":    code == "",
+		"blocked_code": code,
+		"blocked_by":   reason,
+		"checklist":    template,
+		"submissions":  submissions,
+	})
+}
+
+// SubmitInspection: el conductor asignado envía el checklist activo de la flota.
+// JSON {"answers": [...]} o multipart con "answers" (JSON) y las fotos en "photo_<item_id>".
+// Si falla un ítem crítico la ruta no puede iniciarse y se avisa al Admin.
+func SubmitInspection(c *gin.Context) {
+	var input SubmitInspectionInput
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		if err := json.Unmarshal([]byte(c.PostForm("answers")), &input.Answers); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El campo 'answers' debe ser un JSON válido"})
+			return
+		}
+	} else if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	route, ok := loadRoute(c, true)
+	if !ok {
+		return
+	}
+	if route.Status == "in_progress" || route.Status == "completed" || route.Status == "cancelled" {
+		c.JSON(http.StatusConflict, gin.H{"error": "La inspección pre-viaje se hace antes de iniciar la ruta"})
+		return
+	}
+
+	template, err := inspection.ActiveTemplate(database.DB, route.CreatorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo checklist"})
+		return
+	}
+	if template == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "La flota no tiene un checklist activo"})
+		return
+	}
+
+	// 1. Indexar respuestas por ítem
+	byItem := make(map[uuid.UUID]AnswerInput, len(input.Answers))
+	for _, answer := range input.Answers {
+		byItem[answer.ItemID] = answer
+	}
+	for itemID := range byItem {
+		if !hasItem(template, itemID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("El ítem %s no es del checklist activo", itemID)})
+			return
+		}
+	}
+
+	// 2. Validar y calificar cada ítem, fotos incluidas (se suben al final, cuando todo es válido)
+	submission := domains.InspectionSubmission{
+		ID:         uuid.New(),
+		RouteID:    route.ID,
+		TemplateID: template.ID,
+		DriverID:   *route.DriverID,
+		AdminID:    route.CreatorID,
+	}
+	for i := range template.Items {
+		item := &template.Items[i]
+		in := byItem[item.ID]
+		answer := domains.InspectionAnswer{
+			ItemID:      item.ID,
+			Label:       item.Label,
+			Type:        item.Type,
+			Critical:    item.Critical,
+			BoolValue:   in.BoolValue,
+			TextValue:   strings.TrimSpace(in.TextValue),
+			NumberValue: in.NumberValue,
+		}
+
+		answered := true
+		switch item.Type {
+		case domains.ChecklistItemBoolean:
+			answered = answer.BoolValue != nil
+		case domains.ChecklistItemNumber:
+			answered = answer.NumberValue != nil
+		case domains.ChecklistItemText:
+			answered = answer.TextValue != ""
+		case domains.ChecklistItemPhoto:
+			fileHeader, err := c.FormFile(photoField(item.ID))
+			answered = err == nil
+			if answered && (fileHeader.Size > maxPhotoSize || !strings.HasPrefix(fileHeader.Header.Get("Content-Type"), "image/")) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("\"%s\": la foto debe ser una imagen de hasta 10 MB", item.Label)})
+				return
+			}
+		}
+		if item.Required && !answered {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Falta responder \"%s\"", item.Label)})
+			return
+		}
+
+		answer.Failed = inspection.Failed(item, &answer)
+		if answer.Failed {
+			submission.FailedCount++
+			if item.Critical {
+				submission.CriticalFailed++
+			}
+		}
+		submission.Answers = append(submission.Answers, answer)
+	}
+	submission.Passed = submission.CriticalFailed == 0
+
+	// 3. Fotos (ya validadas). Si algo falla después, se borran las que se subieron.
+	svc := storage.NewService()
+	var uploaded []string
+	discardPhotos := func() {
+		if err := svc.DeleteFiles(uploaded...); err != nil {
+			log.Printf("Error borrando fotos de inspección descartada (ruta %s): %v", route.ID, err)
+		}
+	}
+	for i := range submission.Answers {
+		answer := &submission.Answers[i]
+		if answer.Type != domains.ChecklistItemPhoto {
+			continue
+		}
+		fileHeader, err := c.FormFile(photoField(answer.ItemID))
+		if err != nil {
+			continue
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			discardPhotos()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error abriendo archivo"})
+			return
+		}
+		path, err := svc.UploadFile(file, fileHeader.Filename, fileHeader.Header.Get("Content-Type"))
+		file.Close()
+		if err != nil {
+			discardPhotos()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error subiendo foto: " + err.Error()})
+			return
+		}
+		answer.PhotoPath = path
+		uploaded = append(uploaded, path)
+	}
+
+	// 4. Guardar + avisar al Admin si falló algo crítico
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&submission).Error; err != nil {
+			return err
+		}
+		if !submission.Passed {
+			return inbox.NotifyInspectionFailed(tx, &submission, route)
+		}
+		return nil
+	})
+	if err != nil {
+		discardPhotos()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error guardando inspección"})
+		return
+	}
+
+	submissions := []domains.InspectionSubmission{submission}
+	signPhotos(submissions)
+	c.JSON(http.StatusCreated, submissions[0])
+}
+
+// loadRoute busca la ruta de :id y valida el acceso: el conductor asignado siempre;
+// si onlyDriver es false, también el Admin que la creó y los Super Admins.
+// Si falla, ya respondió al cliente.
+func loadRoute(c *gin.Context, onlyDriver bool) (*domains.Route, bool) {
+	userID, _ := c.Get("userID")
+
+	var route domains.Route
+	if err := database.DB.First(&route, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ruta no encontrada"})
+		return nil, false
+	}
+	if route.DriverID != nil && route.DriverID.String() == userID {
+		return &route, true
+	}
+	if onlyDriver {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Solo el conductor asignado puede enviar la inspección"})
+		return nil, false
+	}
+
+	var user domains.User
+	if err := database.DB.Select("id, role").First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no encontrado"})
+		return nil, false
+	}
+	if user.Role != "super_admin" && !(user.Role == "admin" && route.CreatorID == user.ID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "No tienes acceso a esta ruta"})
+		return nil, false
+	}
+	return &route, true
+}
+
+func hasItem(template *domains.ChecklistTemplate, itemID uuid.UUID) bool {
+	for _, item := range template.Items {
+		if item.ID == itemID {
+			return true
+		}
+	}
+	return false
+}
+
+func photoField(itemID uuid.UUID) string {
+	return "photo_" + itemID.String()
+}
+
+// signPhotos firma las fotos de las respuestas para que el front pueda mostrarlas
+func signPhotos(submissions []domains.InspectionSubmission) {
+	svc := storage.NewService()
+	for i := range submissions {
+		for j := range submissions[i].Answers {
+			answer := &submissions[i].Answers[j]
+			if answer.PhotoPath != "" {
+				answer.PhotoURL, _ = svc.GetSignedURL(answer.PhotoPath)
+			}
+		}
+	}
+}
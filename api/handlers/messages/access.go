@@ -0,0 +1,99 @@
+package messages
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+)
+
+// thread identifica un hilo: el general de la ruta (waypoint nil) o el de una parada
+type thread struct {
+	route    *domains.Route
+	waypoint *domains.Waypoint
+	user     *domains.User
+
+	// movedAway: la parada ya está en otra ruta; su hilo en esta queda como historial (solo lectura)
+	movedAway bool
+}
+
+// scope filtra los mensajes del hilo.
+// Los hilos pertenecen a su ruta: si la parada se mueve (reprogramada, dividida, fusionada)
+// la conversación vieja queda en la ruta original y en la nueva empieza un hilo propio.
+func (t *thread) scope(db *gorm.DB) *gorm.DB {
+	if t.waypoint != nil {
+		return db.Where("route_id = ? AND waypoint_id = ?", t.route.ID, t.waypoint.ID)
+	}
+	return db.Where("route_id = ? AND waypoint_id IS NULL", t.route.ID)
+}
+
+func (t *thread) waypointID() *uuid.UUID {
+	if t.waypoint == nil {
+		return nil
+	}
+	return &t.waypoint.ID
+}
+
+// loadThread valida la ruta de la URL, el acceso y la parada (waypoint_id opcional).
+// Si falla, ya respondió al cliente.
+func loadThread(c *gin.Context, rawWaypointID string) (*thread, bool) {
+	userID, _ := c.Get("userID")
+
+	var user domains.User
+	if err := database.DB.Select("id, role, full_name").First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no encontrado"})
+		return nil, false
+	}
+
+	var route domains.Route
+	if err := database.DB.First(&route, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ruta no encontrada"})
+		return nil, false
+	}
+
+	if !canChat(&user, &route) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "No participas en los mensajes de esta ruta"})
+		return nil, false
+	}
+
+	t := &thread{route: &route, user: &user}
+	if rawWaypointID != "" {
+		var wp domains.Waypoint
+		if err := database.DB.First(&wp, "id = ?", rawWaypointID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "La parada no pertenece a esta ruta"})
+			return nil, false
+		}
+		t.waypoint = &wp
+
+		// Una parada que se movió a otra ruta sigue listando su hilo aquí si tuvo mensajes
+		if wp.RouteID != route.ID {
+			var count int64
+			if err := t.scope(database.DB.Model(&domains.RouteMessage{})).Count(&count).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error buscando el hilo"})
+				return nil, false
+			}
+			if count == 0 {
+				c.JSON(http.StatusNotFound, gin.H{"error": "La parada no pertenece a esta ruta"})
+				return nil, false
+			}
+			t.movedAway = true
+		}
+	}
+	return t, true
+}
+
+// canChat: solo el Admin que creó la ruta, su conductor asignado y los Super Admins
+func canChat(user *domains.User, route *domains.Route) bool {
+	switch user.Role {
+	case "super_admin":
+		return true
+	case "admin":
+		return route.CreatorID == user.ID
+	default:
+		return route.DriverID != nil && *route.DriverID == user.ID
+	}
+}
@@ -0,0 +1,251 @@
+package messages
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/realtime"
+	"github.com/tu-usuario/route-manager/api/services/storage"
+)
+
+const (
+	maxBodyLength     = 2000
+	maxAttachmentSize = 10 << 20 // 10 MB
+	pageSize          = 100
+)
+
+// SendMessageInput: JSON o multipart (para adjuntar una imagen en "attachment")
+type SendMessageInput struct {
+	Body       string `json:"body" form:"body"`
+	WaypointID string `json:"waypoint_id" form:"waypoint_id"`
+}
+
+type MarkReadInput struct {
+	WaypointID string `json:"waypoint_id"`
+}
+
+// ThreadSummary: un hilo de la ruta con su último mensaje y los no leídos del usuario
+type ThreadSummary struct {
+	WaypointID    *uuid.UUID `json:"waypoint_id"` // nil = hilo general de la ruta
+	MessageCount  int64      `json:"message_count"`
+	UnreadCount   int64      `json:"unread_count"`
+	LastMessageAt time.Time  `json:"last_message_at"`
+}
+
+// ListMessages devuelve un hilo en orden cronológico (?waypoint_id= para el de una parada).
+// Para polling sin SSE: ?after=<created_at del último mensaje recibido>
+func ListMessages(c *gin.Context) {
+	t, ok := loadThread(c, c.Query("waypoint_id"))
+	if !ok {
+		return
+	}
+
+	query := t.scope(database.DB)
+	if raw := c.Query("after"); raw != "" {
+		after, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parámetro 'after' inválido (RFC3339)"})
+			return
+		}
+		query = query.Where("created_at > ?", after)
+	}
+
+	var msgs []domains.RouteMessage
+	err := query.
+		Preload("Sender", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, full_name, avatar_url, role")
+		}).
+		Preload("Receipts").
+		Order("created_at ASC").
+		Limit(pageSize + 1).
+		Find(&msgs).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error listando mensajes"})
+		return
+	}
+
+	hasMore := len(msgs) > pageSize
+	if hasMore {
+		msgs = msgs[:pageSize]
+	}
+	signAttachments(msgs)
+
+	c.JSON(http.StatusOK, gin.H{"messages": msgs, "has_more": hasMore})
+}
+
+// SendMessage publica un mensaje de texto y/o una imagen en el hilo
+func SendMessage(c *gin.Context) {
+	var input SendMessageInput
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	t, ok := loadThread(c, input.WaypointID)
+	if !ok {
+		return
+	}
+	if t.movedAway {
+		c.JSON(http.StatusConflict, gin.H{"error": "La parada se movió a otra ruta: escribe en el hilo de su ruta actual"})
+		return
+	}
+
+	body := strings.TrimSpace(input.Body)
+	if utf8.RuneCountInString(body) > maxBodyLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El mensaje es demasiado largo (máx. 2000 caracteres)"})
+		return
+	}
+
+	// Adjunto opcional (solo imágenes)
+	var attachmentPath *string
+	if fileHeader, err := c.FormFile("attachment"); err == nil {
+		if fileHeader.Size > maxAttachmentSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "La imagen supera los 10 MB"})
+			return
+		}
+		contentType := fileHeader.Header.Get("Content-Type")
+		if !strings.HasPrefix(contentType, "image/") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Solo se pueden adjuntar imágenes"})
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error abriendo archivo"})
+			return
+		}
+		defer file.Close()
+
+		path, err := storage.NewService().UploadFile(file, fileHeader.Filename, contentType)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error subiendo imagen: " + err.Error()})
+			return
+		}
+		attachmentPath = &path
+	}
+
+	if body == "" && attachmentPath == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El mensaje necesita texto o una imagen"})
+		return
+	}
+
+	msg := domains.RouteMessage{
+		RouteID:        t.route.ID,
+		WaypointID:     t.waypointID(),
+		SenderID:       t.user.ID,
+		Body:           body,
+		AttachmentPath: attachmentPath,
+	}
+	if err := database.DB.Create(&msg).Error; err != nil {
+		// El mensaje no se guardó: la imagen quedaría huérfana en el bucket
+		if attachmentPath != nil {
+			if err := storage.NewService().DeleteFiles(*attachmentPath); err != nil {
+				log.Printf("Error borrando adjunto de mensaje no enviado (ruta %s): %v", t.route.ID, err)
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error enviando mensaje"})
+		return
+	}
+
+	msg.Sender = t.user
+	msg.Receipts = []domains.MessageReceipt{}
+	if msg.AttachmentPath != nil {
+		msg.AttachmentURL, _ = storage.NewService().GetSignedURL(*msg.AttachmentPath)
+	}
+
+	realtime.Publish(realtime.RouteEvent(realtime.MessageCreated, t.route, msg))
+
+	c.JSON(http.StatusCreated, msg)
+}
+
+// MarkThreadRead deja acuse de lectura en todos los mensajes ajenos del hilo
+func MarkThreadRead(c *gin.Context) {
+	var input MarkReadInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	t, ok := loadThread(c, input.WaypointID)
+	if !ok {
+		return
+	}
+
+	var unreadIDs []uuid.UUID
+	err := t.scope(database.DB.Model(&domains.RouteMessage{})).
+		Where("sender_id <> ?", t.user.ID).
+		Where("NOT EXISTS (SELECT 1 FROM message_receipts r WHERE r.message_id = route_messages.id AND r.user_id = ?)", t.user.ID).
+		Pluck("id", &unreadIDs).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error marcando mensajes"})
+		return
+	}
+
+	now := time.Now()
+	if len(unreadIDs) > 0 {
+		receipts := make([]domains.MessageReceipt, 0, len(unreadIDs))
+		for _, id := range unreadIDs {
+			receipts = append(receipts, domains.MessageReceipt{MessageID: id, UserID: t.user.ID, ReadAt: now})
+		}
+		if err := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&receipts).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error marcando mensajes"})
+			return
+		}
+
+		realtime.Publish(realtime.RouteEvent(realtime.MessagesRead, t.route, gin.H{
+			"waypoint_id": t.waypointID(),
+			"user_id":     t.user.ID,
+			"message_ids": unreadIDs,
+			"read_at":     now,
+		}))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Mensajes marcados como leídos", "updated": len(unreadIDs)})
+}
+
+// ListThreads resume los hilos de la ruta (sirve de polling liviano si no hay SSE)
+func ListThreads(c *gin.Context) {
+	t, ok := loadThread(c, "")
+	if !ok {
+		return
+	}
+
+	var threads []ThreadSummary
+	err := database.DB.Model(&domains.RouteMessage{}).
+		Select(`waypoint_id,
+			COUNT(*) AS message_count,
+			COUNT(*) FILTER (WHERE sender_id <> ? AND NOT EXISTS (
+				SELECT 1 FROM message_receipts r WHERE r.message_id = route_messages.id AND r.user_id = ?)) AS unread_count,
+			MAX(created_at) AS last_message_at`, t.user.ID, t.user.ID).
+		Where("route_id = ?", t.route.ID).
+		Group("waypoint_id").
+		Order("last_message_at DESC").
+		Scan(&threads).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error listando hilos"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"threads": threads})
+}
+
+// signAttachments firma las imágenes adjuntas para que el front pueda mostrarlas
+func signAttachments(msgs []domains.RouteMessage) {
+	svc := storage.NewService()
+	for i := range msgs {
+		if msgs[i].AttachmentPath != nil && *msgs[i].AttachmentPath != "" {
+			msgs[i].AttachmentURL, _ = svc.GetSignedURL(*msgs[i].AttachmentPath)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package notifications
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+// inboxCursor apunta a la última notificación entregada (created_at + ID como desempate),
+// con el mismo formato que el cursor del listado de rutas
+type inboxCursor struct {
+	Sort  string    `json:"s"`
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+// ListNotifications devuelve la bandeja del usuario logueado, de la más nueva a la más vieja.
+// Parámetros: unread=true (solo no leídas), limit, cursor (next_cursor de la página anterior)
+func ListNotifications(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	query := database.DB.Where("user_id = ?", userID)
+	if c.Query("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, createdAt, err := decodeCursor(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cursor inválido"})
+			return
+		}
+		// Varias notificaciones pueden compartir created_at (ej: un aviso a toda la flota)
+		query = query.Where("(created_at < ?) OR (created_at = ? AND id < ?)", createdAt, createdAt, cursor.ID)
+	}
+
+	limit := defaultLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parámetro 'limit' inválido"})
+			return
+		}
+		if n > maxLimit {
+			n = maxLimit
+		}
+		limit = n
+	}
+
+	var items []domains.Notification
+	if err := query.Order("created_at DESC, id DESC").Limit(limit + 1).Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error listando notificaciones"})
+		return
+	}
+
+	hasMore := len(items) > limit
+	if hasMore {
+		items = items[:limit]
+	}
+	nextCursor := ""
+	if hasMore {
+		last := items[len(items)-1]
+		nextCursor = encodeCursor(last.ID, last.CreatedAt)
+	}
+
+	var unread int64
+	if err := database.DB.Model(&domains.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).Count(&unread).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error listando notificaciones"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":         items,
+		"unread_count": unread,
+		"pagination": gin.H{
+			"limit":       limit,
+			"has_more":    hasMore,
+			"next_cursor": nextCursor,
+		},
+	})
+}
+
+// MarkRead marca una notificación propia como leída (idempotente)
+func MarkRead(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var notification domains.Notification
+	if err := database.DB.First(&notification, "id = ? AND user_id = ?", c.Param("id"), userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notificación no encontrada"})
+		return
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		if err := database.DB.Model(&notification).Update("read_at", now).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error marcando notificación"})
+			return
+		}
+		notification.ReadAt = &now
+	}
+
+	c.JSON(http.StatusOK, notification)
+}
+
+// MarkAllRead marca como leídas todas las notificaciones pendientes del usuario
+func MarkAllRead(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	result := database.DB.Model(&domains.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error marcando notificaciones"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notificaciones marcadas como leídas", "updated": result.RowsAffected})
+}
+
+func encodeCursor(id uuid.UUID, createdAt time.Time) string {
+	raw, _ := json.Marshal(inboxCursor{Sort: "created_at", Value: createdAt.Format(time.RFC3339Nano), ID: id})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(raw string) (*inboxCursor, time.Time, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var cursor inboxCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, time.Time{}, err
+	}
+	if cursor.Sort != "created_at" {
+		return nil, time.Time{}, errors.New("cursor de otro orden")
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, cursor.Value)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return &cursor, createdAt, nil
+}
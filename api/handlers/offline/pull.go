@@ -0,0 +1,158 @@
+package offline
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+)
+
+const (
+	// syncOverlap: se relee este margen antes del cursor para no perder cambios de
+	// transacciones que confirmaron tarde. Como se envía el estado completo, repetir es inofensivo.
+	syncOverlap = 30 * time.Second
+
+	// maxSyncRoutes: si cambiaron más rutas, se manda una foto completa (reset)
+	maxSyncRoutes = 200
+)
+
+// syncCursor es opaco para el cliente
+type syncCursor struct {
+	At time.Time `json:"t"`
+}
+
+func encodeCursor(at time.Time) string {
+	raw, _ := json.Marshal(syncCursor{At: at})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (time.Time, bool) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return time.Time{}, false
+	}
+	var cur syncCursor
+	if err := json.Unmarshal(raw, &cur); err != nil || cur.At.IsZero() {
+		return time.Time{}, false
+	}
+	return cur.At, true
+}
+
+// PullResponse: Routes trae el estado completo (con paradas) de cada ruta que cambió;
+// RemovedRouteIDs las que el usuario ya no debe tener (borradas o reasignadas)
+type PullResponse struct {
+	Cursor          string          `json:"cursor"`
+	Reset           bool            `json:"reset"` // true = reemplazar todo lo local por Routes
+	Routes          []domains.Route `json:"routes"`
+	RemovedRouteIDs []uuid.UUID     `json:"removed_route_ids"`
+}
+
+// PullChanges devuelve los cambios de rutas y paradas visibles para el usuario desde ?since=<cursor>.
+// Sin cursor (o si cambió demasiado) devuelve la foto completa de las rutas abiertas.
+// Los cambios se detectan con la auditoría, que se escribe en la misma transacción que cada cambio.
+func PullChanges(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var user domains.User
+	if err := database.DB.Select("id, role").First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no encontrado"})
+		return
+	}
+
+	// El nuevo cursor se toma antes de leer: lo que se confirme durante la lectura vuelve en el próximo pull
+	now := time.Now()
+	resp := PullResponse{Cursor: encodeCursor(now), Routes: []domains.Route{}, RemovedRouteIDs: []uuid.UUID{}}
+
+	var routeIDs []uuid.UUID
+	reset := true
+	if raw := c.Query("since"); raw != "" {
+		since, ok := decodeCursor(raw)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cursor inválido"})
+			return
+		}
+
+		changed := database.DB.Model(&domains.AuditLog{}).
+			Where("created_at > ? AND route_id IS NOT NULL", since.Add(-syncOverlap))
+		if err := scopeChanges(changed, &user).
+			Distinct("route_id").Limit(maxSyncRoutes+1).
+			Pluck("route_id", &routeIDs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo cambios"})
+			return
+		}
+		reset = len(routeIDs) > maxSyncRoutes
+	}
+
+	var routes []domains.Route
+	query := database.DB.Unscoped().Preload("Waypoints", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sequence_order ASC")
+	})
+	if reset {
+		// Foto completa: las rutas abiertas que el usuario ve (mismas reglas que el listado)
+		query = scopeRoutes(query.Where("deleted_at IS NULL AND status NOT IN ?", []string{"completed", "cancelled"}), &user)
+	} else if len(routeIDs) > 0 {
+		query = query.Where("id IN ?", routeIDs)
+	} else {
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	if err := query.Find(&routes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo rutas"})
+		return
+	}
+
+	resp.Reset = reset
+	for _, route := range routes {
+		if route.DeletedAt.Valid || !canSeeRoute(&user, &route) {
+			resp.RemovedRouteIDs = append(resp.RemovedRouteIDs, route.ID)
+			continue
+		}
+		resp.Routes = append(resp.Routes, route)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// scopeChanges limita la auditoría a las rutas que el usuario ve o veía
+// (al conductor también le interesan las rutas que le quitaron)
+func scopeChanges(query *gorm.DB, user *domains.User) *gorm.DB {
+	switch user.Role {
+	case "super_admin":
+		return query
+	case "admin":
+		return query.Where("owner_id = ?", user.ID)
+	default:
+		mine := database.DB.Unscoped().Model(&domains.Route{}).Select("id").Where("driver_id = ?", user.ID)
+		return query.Where("route_id IN (?) OR changes->'driver_id'->>'from' = ?", mine, user.ID.String())
+	}
+}
+
+// scopeRoutes aplica las reglas de visibilidad de ListRoutes
+func scopeRoutes(query *gorm.DB, user *domains.User) *gorm.DB {
+	switch user.Role {
+	case "super_admin":
+		return query
+	case "admin":
+		return query.Where("creator_id = ?", user.ID)
+	default:
+		return query.Where("driver_id = ?", user.ID)
+	}
+}
+
+func canSeeRoute(user *domains.User, route *domains.Route) bool {
+	switch user.Role {
+	case "super_admin":
+		return true
+	case "admin":
+		return route.CreatorID == user.ID
+	default:
+		return route.DriverID != nil && *route.DriverID == user.ID
+	}
+}
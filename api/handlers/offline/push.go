@@ -0,0 +1,318 @@
+package offline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/audit"
+	"github.com/tu-usuario/route-manager/api/services/dwell"
+	"github.com/tu-usuario/route-manager/api/services/fleet"
+	"github.com/tu-usuario/route-manager/api/services/geofence"
+	"github.com/tu-usuario/route-manager/api/services/notify"
+	"github.com/tu-usuario/route-manager/api/services/realtime"
+	"github.com/tu-usuario/route-manager/api/services/webhooks"
+)
+
+const maxSyncOperations = 100
+
+// errRetry: falla transitoria, el resultado no se guarda para que el cliente reintente
+var errRetry = errors.New("reintentar")
+
+// Operation es una acción que el conductor hizo sin conexión
+type Operation struct {
+	ID          string          `json:"id" binding:"required"` // UUID generado por el cliente (idempotencia)
+	Type        string          `json:"type" binding:"required,oneof=complete fail notes"`
+	WaypointID  string          `json:"waypoint_id" binding:"required"`
+	ClientTime  time.Time       `json:"client_time" binding:"required"`
+	BaseVersion *int            `json:"base_version"` // Versión de la parada que vio el cliente (solo "notes")
+	Data        json.RawMessage `json:"data"`
+}
+
+type PushInput struct {
+	Operations []Operation `json:"operations" binding:"required,min=1,dive"`
+}
+
+// OperationResult es el resultado de cada operación, en el mismo orden del lote
+type OperationResult struct {
+	ID        string `json:"id"`
+	Status    string `json:"status"` // applied, skipped, conflict, rejected, error (reintentar)
+	Code      string `json:"code,omitempty"`
+	Message   string `json:"message,omitempty"`
+	Version   int    `json:"version,omitempty"`
+	Duplicate bool   `json:"duplicate,omitempty"` // Ya se había procesado: se devuelve el resultado original
+}
+
+type completeData struct {
+	RecipientName string   `json:"recipient_name"`
+	Latitude      *float64 `json:"latitude"`
+	Longitude     *float64 `json:"longitude"`
+	Accuracy      *float64 `json:"accuracy"`
+}
+
+type failData struct {
+	ReasonCode string `json:"reason_code"`
+	Notes      string `json:"notes"`
+}
+
+type notesData struct {
+	Notes string `json:"notes"`
+}
+
+// PushOperations (Conductor) aplica, en orden, las operaciones generadas sin conexión.
+// Cada una se resuelve por separado (una falla no frena las demás) con estas reglas:
+//   - complete: la entrega es un hecho del terreno, se aplica aunque la parada haya cambiado
+//     (ej: dirección corregida). Si ya estaba completada → skipped. Respeta la geocerca.
+//   - fail: si la parada ya fue entregada → conflict (gana la entrega). Si no, el intento
+//     queda en el historial y el último fallo se actualiza solo si es más reciente.
+//   - notes: si se envía base_version y la parada cambió → conflict (gana el servidor).
+func PushOperations(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var input PushInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(input.Operations) > maxSyncOperations {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Máximo %d operaciones por lote", maxSyncOperations)})
+		return
+	}
+
+	driverID, err := uuid.Parse(fmt.Sprint(userID))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario inválido"})
+		return
+	}
+
+	results := make([]OperationResult, 0, len(input.Operations))
+	for _, op := range input.Operations {
+		results = append(results, processOperation(c, driverID, op))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+func processOperation(c *gin.Context, driverID uuid.UUID, op Operation) OperationResult {
+	opID, err := uuid.Parse(op.ID)
+	if err != nil {
+		return OperationResult{ID: op.ID, Status: domains.SyncRejected, Code: "INVALID_ID", Message: "El id de la operación debe ser un UUID"}
+	}
+
+	// 1. ¿Ya se procesó? (el cliente reenvía el lote si perdió la respuesta)
+	var previous domains.SyncOperation
+	if database.DB.First(&previous, "id = ?", opID).Error == nil {
+		if previous.UserID != driverID {
+			return OperationResult{ID: op.ID, Status: domains.SyncRejected, Code: "DUPLICATE_ID", Message: "El id de la operación ya está en uso"}
+		}
+		return OperationResult{ID: op.ID, Status: previous.Status, Code: previous.Code, Message: previous.Message, Version: previous.Version, Duplicate: true}
+	}
+
+	record := domains.SyncOperation{ID: opID, UserID: driverID, Type: op.Type, ClientTime: op.ClientTime}
+	if err := applyOperation(c, driverID, op, &record); errors.Is(err, errRetry) {
+		return OperationResult{ID: op.ID, Status: "error", Message: "Error temporal, reintentar"}
+	}
+	return OperationResult{ID: op.ID, Status: record.Status, Code: record.Code, Message: record.Message, Version: record.Version}
+}
+
+// applyOperation completa record con el resultado y lo guarda (si se aplicó, en la misma transacción).
+// Devuelve errRetry si fue una falla transitoria.
+func applyOperation(c *gin.Context, driverID uuid.UUID, op Operation, record *domains.SyncOperation) error {
+	finish := func(status, code, message string) error {
+		record.Status, record.Code, record.Message = status, code, message
+		if err := database.DB.Create(record).Error; err != nil {
+			return errRetry
+		}
+		return nil
+	}
+
+	// 2. Validaciones comunes
+	waypointID, err := uuid.Parse(op.WaypointID)
+	if err != nil {
+		return finish(domains.SyncRejected, "INVALID_WAYPOINT", "waypoint_id inválido")
+	}
+	record.WaypointID = waypointID
+
+	now := time.Now()
+	if op.ClientTime.After(now.Add(5 * time.Minute)) {
+		return finish(domains.SyncRejected, "FUTURE_TIME", "client_time no puede estar en el futuro")
+	}
+
+	var wp domains.Waypoint
+	if err := database.DB.Preload("Route").First(&wp, "id = ?", waypointID).Error; err != nil {
+		return finish(domains.SyncRejected, "NOT_FOUND", "Punto no encontrado")
+	}
+	if wp.Route.DriverID == nil || *wp.Route.DriverID != driverID {
+		return finish(domains.SyncRejected, "FORBIDDEN", "Sin permiso sobre esta parada")
+	}
+	record.Version = wp.Version
+
+	// 3. Reglas por tipo
+	before := wp
+	var action string
+	var attempt *domains.DeliveryAttempt
+	var proof *domains.ProofOfDelivery
+	var extra map[string]audit.Change
+	var event string
+
+	switch op.Type {
+	case "complete":
+		var data completeData
+		if len(op.Data) > 0 && json.Unmarshal(op.Data, &data) != nil {
+			return finish(domains.SyncRejected, "INVALID_DATA", "data inválida")
+		}
+		if wp.IsCompleted {
+			return finish(domains.SyncSkipped, "ALREADY_COMPLETED", "La parada ya estaba entregada")
+		}
+		if (data.Latitude == nil) != (data.Longitude == nil) {
+			return finish(domains.SyncRejected, "INVALID_DATA", "latitude y longitude van juntos")
+		}
+
+		policy, err := fleet.Settings(wp.Route.CreatorID)
+		if err != nil {
+			return errRetry
+		}
+		verdict := geofence.Evaluate(policy, &wp, data.Latitude, data.Longitude, data.Accuracy)
+		if verdict.Result == geofence.Rejected {
+			return finish(domains.SyncRejected, "GEOFENCE_REJECTED", "Demasiado lejos de la parada para completarla")
+		}
+
+		completedAt := op.ClientTime
+		wp.IsCompleted = true
+		wp.CompletedAt = &completedAt
+		wp.FailedAt = nil
+		wp.FailureReason = ""
+		dwell.InferArrival(&wp, data.Latitude, data.Longitude, completedAt, policy.GeofenceAcceptRadiusM)
+
+		proof = &domains.ProofOfDelivery{
+			WaypointID:     wp.ID,
+			RecipientName:  data.RecipientName,
+			Latitude:       data.Latitude,
+			Longitude:      data.Longitude,
+			AccuracyM:      data.Accuracy,
+			CapturedAt:     completedAt,
+			GeofenceResult: verdict.Result,
+			GeofenceReason: verdict.Reason,
+			DistanceM:      verdict.DistanceM,
+		}
+		attempt = &domains.DeliveryAttempt{Outcome: "delivered"}
+		extra = map[string]audit.Change{"geofence": {From: nil, To: verdict}}
+		action, event = "complete", realtime.WaypointCompleted
+
+	case "fail":
+		var data failData
+		if json.Unmarshal(op.Data, &data) != nil || !validReasons[data.ReasonCode] {
+			return finish(domains.SyncRejected, "INVALID_DATA", "reason_code inválido")
+		}
+		if data.ReasonCode == domains.ReasonOther && data.Notes == "" {
+			return finish(domains.SyncRejected, "INVALID_DATA", "Con motivo 'other' las notas son obligatorias")
+		}
+		if wp.IsCompleted {
+			return finish(domains.SyncConflict, "ALREADY_COMPLETED", "La parada ya fue entregada")
+		}
+
+		if wp.FailedAt == nil || op.ClientTime.After(*wp.FailedAt) {
+			failedAt := op.ClientTime
+			wp.FailedAt = &failedAt
+			wp.FailureReason = data.ReasonCode
+		}
+		attempt = &domains.DeliveryAttempt{Outcome: "failed", ReasonCode: data.ReasonCode, Notes: data.Notes}
+		action, event = "fail", realtime.WaypointFailed
+
+	case "notes":
+		var data notesData
+		if json.Unmarshal(op.Data, &data) != nil {
+			return finish(domains.SyncRejected, "INVALID_DATA", "data inválida")
+		}
+		if op.BaseVersion != nil && *op.BaseVersion != wp.Version {
+			return finish(domains.SyncConflict, "VERSION_CONFLICT", "La parada cambió en el servidor: se conserva la versión del servidor")
+		}
+		if wp.Notes == data.Notes {
+			return finish(domains.SyncSkipped, "NO_CHANGES", "")
+		}
+		wp.Notes = data.Notes
+		action = "update"
+	}
+
+	if attempt != nil {
+		attempt.WaypointID = wp.ID
+		attempt.RouteID = wp.RouteID
+		attempt.DriverID = driverID
+		attempt.AttemptedAt = op.ClientTime
+	}
+
+	// 4. Guardar cambio + resultado en una sola transacción
+	record.Status = domains.SyncApplied
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := database.SaveVersioned(tx, &wp); err != nil {
+			return err
+		}
+		if attempt != nil {
+			if err := tx.Create(attempt).Error; err != nil {
+				return err
+			}
+		}
+		if proof != nil {
+			if err := tx.Create(proof).Error; err != nil {
+				return err
+			}
+			extra["proof_id"] = audit.Change{From: nil, To: proof.ID}
+		}
+		switch op.Type {
+		case "complete":
+			if err := webhooks.EnqueueWaypointCompleted(tx, &wp.Route, &wp, proof); err != nil {
+				return err
+			}
+		case "fail":
+			if err := webhooks.EnqueueWaypointFailed(tx, &wp.Route, &wp, attempt); err != nil {
+				return err
+			}
+		}
+		if op.Type == "complete" || op.Type == "fail" {
+			if err := notify.EnqueueStopResolved(tx, &wp.Route, &wp); err != nil {
+				return err
+			}
+		}
+
+		record.Version = wp.Version
+		if err := tx.Create(record).Error; err != nil {
+			return err
+		}
+
+		entry := audit.WaypointEntry(action, &wp.Route, &before, &wp)
+		if extra == nil {
+			extra = map[string]audit.Change{}
+		}
+		extra["sync_operation_id"] = audit.Change{From: nil, To: record.ID}
+		entry.Extra = extra
+		return audit.Record(tx, audit.FromContext(c), entry)
+	})
+	if err != nil {
+		// Versión vieja (alguien la cambió mientras tanto) o falla de BD: el cliente reintenta
+		return errRetry
+	}
+
+	if event != "" {
+		realtime.Publish(realtime.RouteEvent(event, &wp.Route, gin.H{
+			"waypoint_id": wp.ID,
+			"via":         "sync",
+		}))
+	}
+	return nil
+}
+
+var validReasons = map[string]bool{
+	domains.ReasonCustomerAbsent:  true,
+	domains.ReasonAddressNotFound: true,
+	domains.ReasonRefused:         true,
+	domains.ReasonDamaged:         true,
+	domains.ReasonOther:           true,
+}
@@ -0,0 +1,70 @@
+package routes
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/optimization"
+	"github.com/tu-usuario/route-manager/api/utils"
+)
+
+// currentUser carga id, rol y jefe del usuario logueado. Si falla, ya respondió al cliente.
+func currentUser(c *gin.Context) (*domains.User, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return nil, false
+	}
+
+	var user domains.User
+	if err := database.DB.Select("id, role, manager_id").First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no encontrado"})
+		return nil, false
+	}
+
+	return &user, true
+}
+
+// canManageRoute: el Super Admin gestiona todo, el Admin solo las rutas que creó
+func canManageRoute(user *domains.User, route *domains.Route) bool {
+	if user.Role == "super_admin" {
+		return true
+	}
+	return user.Role == "admin" && route.CreatorID == user.ID
+}
+
+// respondRouteConflict responde 412 con la versión vigente de la ruta
+func respondRouteConflict(c *gin.Context, routeID uuid.UUID) {
+	var fresh domains.Route
+	database.DB.Select("version").First(&fresh, "id = ?", routeID)
+	utils.RespondVersionConflict(c, fresh.Version)
+}
+
+// isEditableStatus: solo se reestructuran rutas que aún no salieron a la calle
+func isEditableStatus(status string) bool {
+	return status != "in_progress" && status != "completed"
+}
+
+// resequence ordena por SequenceOrder y renumera 1..n sin huecos
+func resequence(waypoints []domains.Waypoint) {
+	sort.SliceStable(waypoints, func(i, j int) bool {
+		return waypoints[i].SequenceOrder < waypoints[j].SequenceOrder
+	})
+	for i := range waypoints {
+		waypoints[i].SequenceOrder = i + 1
+	}
+}
+
+// routeDistance calcula la distancia total respetando el orden de visita
+func routeDistance(waypoints []domains.Waypoint) float64 {
+	ordered := make([]domains.Waypoint, len(waypoints))
+	copy(ordered, waypoints)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].SequenceOrder < ordered[j].SequenceOrder
+	})
+	return optimization.CalculateRouteDistance(ordered)
+}
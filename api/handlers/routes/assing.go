@@ -1,56 +1,142 @@
-package routes
-
-import (
-	"net/http"
-
-	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
-	"github.com/tu-usuario/route-manager/api/database"
-	"github.com/tu-usuario/route-manager/api/domains"
-)
-
-type AssignDriverInput struct {
-	DriverID string `json:"driver_id" binding:"required"`
-}
-
-func AssignDriver(c *gin.Context) {
-	routeID := c.Param("id")
-	var input AssignDriverInput
-
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Validar UUIDs
-	driverUUID, err := uuid.Parse(input.DriverID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de conductor inválido"})
-		return
-	}
-
-	// Verificar que la ruta existe
-	var route domains.Route
-	if err := database.DB.First(&route, "id = ?", routeID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Ruta no encontrada"})
-		return
-	}
-
-	// Verificar que el conductor existe y es conductor
-	var driver domains.User
-	if err := database.DB.First(&driver, "id = ? AND role = 'driver'", driverUUID).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "El usuario no existe o no es un conductor"})
-		return
-	}
-
-	// Actualizar ruta
-	route.DriverID = &driverUUID
-	route.Status = "pending" // Cambia estado a pendiente de inicio
-
-	if err := database.DB.Save(&route).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al asignar"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Ruta asignada a " + driver.FullName, "route": route})
-}
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/audit"
+	"github.com/tu-usuario/route-manager/api/services/availability"
+	"github.com/tu-usuario/route-manager/api/services/inbox"
+	"github.com/tu-usuario/route-manager/api/services/realtime"
+	"github.com/tu-usuario/route-manager/api/services/webhooks"
+	"github.com/tu-usuario/route-manager/api/utils"
+)
+
+type AssignDriverInput struct {
+	DriverID string `json:"driver_id" binding:"required"`
+}
+
+func AssignDriver(c *gin.Context) {
+	routeID := c.Param("id")
+	var input AssignDriverInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validar UUIDs
+	driverUUID, err := uuid.Parse(input.DriverID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de conductor inválido"})
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	// Verificar que la ruta existe
+	var route domains.Route
+	if err := database.DB.First(&route, "id = ?", routeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ruta no encontrada"})
+		return
+	}
+
+	if !canManageRoute(user, &route) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "No tienes permiso sobre esta ruta"})
+		return
+	}
+
+	if !utils.CheckIfMatch(c, route.Version) {
+		return
+	}
+
+	// Verificar que el conductor existe, es conductor y pertenece a la flota dueña de la ruta
+	var driver domains.User
+	if err := database.DB.First(&driver, "id = ? AND role = 'driver'", driverUUID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El usuario no existe o no es un conductor"})
+		return
+	}
+	if driver.ManagerID == nil || *driver.ManagerID != route.CreatorID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El conductor no pertenece a la flota de esta ruta"})
+		return
+	}
+	if driver.Status != "active" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El conductor no está activo"})
+		return
+	}
+
+	// Disponibilidad: ausencias aprobadas o días no laborables bloquean; el resto solo avisa
+	avail, err := availability.Check(driver.ID, route.ScheduledDate, route.EstimatedDurationMin)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error verificando disponibilidad"})
+		return
+	}
+	if !avail.Available {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":        "El conductor no está disponible en la fecha de la ruta",
+			"code":         "DRIVER_UNAVAILABLE",
+			"availability": avail,
+		})
+		return
+	}
+
+	if !assignDriverToRoute(c, &route, &driver, "assign") {
+		return
+	}
+
+	c.Header("ETag", utils.ETag(route.Version))
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Ruta asignada a " + driver.FullName,
+		"route":    route,
+		"warnings": avail.Warnings,
+	})
+}
+
+// assignDriverToRoute guarda la asignación (con control de versión y auditoría).
+// Si falla, ya respondió al cliente y devuelve false.
+func assignDriverToRoute(c *gin.Context, route *domains.Route, driver *domains.User, action string) bool {
+	before := *route
+	route.DriverID = &driver.ID
+	route.Status = "pending" // Cambia estado a pendiente de inicio
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := database.SaveVersioned(tx, route); err != nil {
+			return err
+		}
+		if err := webhooks.EnqueueRouteStatusChanged(tx, route, before.Status); err != nil {
+			return err
+		}
+		// Reasignar al mismo conductor no genera aviso
+		if before.DriverID == nil || *before.DriverID != driver.ID {
+			if err := inbox.NotifyRouteAssigned(tx, route, driver.ID); err != nil {
+				return err
+			}
+		}
+		return audit.Record(tx, audit.FromContext(c), audit.RouteEntry(action, &before, route))
+	})
+	if err != nil {
+		if errors.Is(err, database.ErrVersionConflict) {
+			respondRouteConflict(c, route.ID)
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al asignar"})
+		return false
+	}
+
+	realtime.Publish(realtime.RouteEvent(realtime.RouteAssigned, route, gin.H{
+		"driver_id":   driver.ID,
+		"driver_name": driver.FullName,
+		"status":      route.Status,
+	}))
+
+	return true
+}
@@ -0,0 +1,91 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/assignment"
+	"github.com/tu-usuario/route-manager/api/utils"
+)
+
+type AutoAssignInput struct {
+	Apply bool `json:"apply"` // false: solo sugerir. true: asignar al mejor candidato
+}
+
+// AutoAssignDriver puntúa a los conductores activos de la flota (carga del día, horas
+// reservadas, distancia a la primera parada y capacidad) y sugiere o asigna el mejor.
+func AutoAssignDriver(c *gin.Context) {
+	routeID := c.Param("id")
+
+	var input AutoAssignInput
+	if err := c.ShouldBindJSON(&input); err != nil && err.Error() != "EOF" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	// 1. Buscar la ruta con sus paradas
+	var route domains.Route
+	if err := database.DB.Preload("Waypoints").First(&route, "id = ?", routeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ruta no encontrada"})
+		return
+	}
+
+	if !canManageRoute(user, &route) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "No tienes permiso sobre esta ruta"})
+		return
+	}
+
+	if !isEditableStatus(route.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No se puede reasignar una ruta en curso o finalizada"})
+		return
+	}
+
+	// 2. Ranking
+	candidates, err := assignment.Suggest(&route)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error calculando sugerencias"})
+		return
+	}
+
+	if !input.Apply {
+		c.JSON(http.StatusOK, gin.H{"candidates": candidates})
+		return
+	}
+
+	// 3. Asignar al mejor candidato elegible
+	if len(candidates) == 0 || !candidates[0].Eligible {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":      "Ningún conductor de la flota puede tomar esta ruta",
+			"candidates": candidates,
+		})
+		return
+	}
+
+	if !utils.CheckIfMatch(c, route.Version) {
+		return
+	}
+
+	var driver domains.User
+	if err := database.DB.First(&driver, "id = ?", candidates[0].DriverID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Conductor sugerido no encontrado"})
+		return
+	}
+
+	if !assignDriverToRoute(c, &route, &driver, "auto_assign") {
+		return
+	}
+
+	c.Header("ETag", utils.ETag(route.Version))
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Ruta asignada a " + driver.FullName,
+		"route":      route,
+		"candidates": candidates,
+	})
+}
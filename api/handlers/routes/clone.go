@@ -0,0 +1,112 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/audit"
+	"github.com/tu-usuario/route-manager/api/services/webhooks"
+)
+
+type CloneRouteInput struct {
+	Name          string     `json:"name"`           // Por defecto: "<nombre> (copia)"
+	ScheduledDate *time.Time `json:"scheduled_date"` // Por defecto: sin fecha
+	KeepDriver    bool       `json:"keep_driver"`    // Mantener el conductor asignado
+}
+
+// CloneRoute duplica una ruta con IDs nuevos y el estado de entrega limpio
+func CloneRoute(c *gin.Context) {
+	routeID := c.Param("id")
+
+	var input CloneRouteInput
+	// El body es opcional: sin body se usan los valores por defecto
+	if err := c.ShouldBindJSON(&input); err != nil && err.Error() != "EOF" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	// 1. Buscar la ruta original
+	var original domains.Route
+	if err := database.DB.Preload("Waypoints").First(&original, "id = ?", routeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ruta no encontrada"})
+		return
+	}
+
+	if !canManageRoute(user, &original) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "No tienes permiso sobre esta ruta"})
+		return
+	}
+
+	// 2. Copiar paradas con IDs frescos y sin datos de entrega
+	waypoints := make([]domains.Waypoint, 0, len(original.Waypoints))
+	for _, wp := range original.Waypoints {
+		waypoints = append(waypoints, domains.Waypoint{
+			ID:            uuid.New(),
+			Address:       wp.Address,
+			Latitude:      wp.Latitude,
+			Longitude:     wp.Longitude,
+			SequenceOrder: wp.SequenceOrder,
+			CustomerName:  wp.CustomerName,
+			CustomerEmail: wp.CustomerEmail,
+			CustomerPhone: wp.CustomerPhone,
+			Notes:         wp.Notes,
+			IsCompleted:   false,
+		})
+	}
+	resequence(waypoints)
+
+	name := input.Name
+	if name == "" {
+		name = original.Name + " (copia)"
+	}
+
+	clone := domains.Route{
+		ID:                   uuid.New(),
+		CreatorID:            original.CreatorID, // La copia queda en la misma flota
+		Name:                 name,
+		Status:               "draft",
+		ScheduledDate:        input.ScheduledDate,
+		TotalDistanceKm:      original.TotalDistanceKm,
+		EstimatedDurationMin: original.EstimatedDurationMin,
+		Waypoints:            waypoints,
+	}
+
+	if input.KeepDriver && original.DriverID != nil {
+		clone.DriverID = original.DriverID
+		clone.Status = "pending" // Igual que al asignar manualmente
+	}
+
+	// 3. Guardar (ruta + paradas + auditoría en una transacción)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&clone).Error; err != nil {
+			return err
+		}
+		if err := webhooks.EnqueueRouteCreated(tx, &clone); err != nil {
+			return err
+		}
+		entry := audit.RouteEntry("clone", nil, &clone)
+		entry.Extra = map[string]audit.Change{"cloned_from": {From: nil, To: original.ID}}
+		return audit.Record(tx, audit.FromContext(c), entry)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo clonar la ruta: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": fmt.Sprintf("Ruta clonada con %d paradas", len(waypoints)),
+		"route":   clone,
+	})
+}
@@ -0,0 +1,295 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/audit"
+	"github.com/tu-usuario/route-manager/api/services/dwell"
+	"github.com/tu-usuario/route-manager/api/services/webhooks"
+)
+
+// FailedStop es una parada con intento fallido pendiente de reprogramar
+type FailedStop struct {
+	domains.Waypoint
+	RouteName          string     `json:"route_name"`
+	RouteScheduledDate *time.Time `json:"route_scheduled_date"`
+}
+
+// ListFailedStops lista las paradas cuyo último intento falló (y aún no se reprogramaron).
+// Query params opcionales: route_id, reason
+func ListFailedStops(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	query := database.DB.Model(&domains.Waypoint{}).
+		Joins("JOIN routes ON routes.id = waypoints.route_id AND routes.deleted_at IS NULL").
+		Where("waypoints.failed_at IS NOT NULL AND waypoints.is_completed = ?", false)
+
+	if user.Role != "super_admin" {
+		query = query.Where("routes.creator_id = ?", user.ID)
+	}
+	if routeID := c.Query("route_id"); routeID != "" {
+		query = query.Where("waypoints.route_id = ?", routeID)
+	}
+	if reason := c.Query("reason"); reason != "" {
+		query = query.Where("waypoints.failure_reason = ?", reason)
+	}
+
+	var waypoints []domains.Waypoint
+	if err := query.
+		Preload("Route").
+		Preload("Attempts", func(db *gorm.DB) *gorm.DB {
+			return db.Order("attempted_at ASC")
+		}).
+		Order("waypoints.failed_at DESC").
+		Find(&waypoints).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo paradas fallidas"})
+		return
+	}
+
+	stops := make([]FailedStop, 0, len(waypoints))
+	for _, wp := range waypoints {
+		stops = append(stops, FailedStop{
+			Waypoint:           wp,
+			RouteName:          wp.Route.Name,
+			RouteScheduledDate: wp.Route.ScheduledDate,
+		})
+	}
+
+	c.JSON(http.StatusOK, stops)
+}
+
+// MoveFailedStopsInput: se indica UNO de los dos destinos
+type MoveFailedStopsInput struct {
+	WaypointIDs   []string       `json:"waypoint_ids" binding:"required,min=1"`
+	TargetRouteID string         `json:"target_route_id"` // Ruta futura existente
+	NewRoute      *NewRouteInput `json:"new_route"`       // O bien, crear una ruta nueva
+}
+
+type NewRouteInput struct {
+	Name          string     `json:"name"`
+	ScheduledDate *time.Time `json:"scheduled_date" binding:"required"`
+}
+
+// MoveFailedStops reprograma paradas fallidas en una ruta futura (existente o nueva) en una sola acción
+func MoveFailedStops(c *gin.Context) {
+	var input MoveFailedStopsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if (input.TargetRouteID != "") == (input.NewRoute != nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Debes indicar 'target_route_id' o 'new_route' (solo uno)"})
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	// 1. Cargar y validar las paradas
+	ids := make([]uuid.UUID, 0, len(input.WaypointIDs))
+	seen := map[uuid.UUID]bool{}
+	for _, raw := range input.WaypointIDs {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID de parada inválido: " + raw})
+			return
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	var stops []domains.Waypoint
+	if err := database.DB.Preload("Route").Where("id IN ?", ids).Order("sequence_order ASC").Find(&stops).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo paradas"})
+		return
+	}
+	if len(stops) != len(ids) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Algunas paradas no existen"})
+		return
+	}
+
+	var fleetID uuid.UUID
+	for i, wp := range stops {
+		if !canManageRoute(user, &wp.Route) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "No tienes permiso sobre la ruta " + wp.Route.Name})
+			return
+		}
+		if wp.IsCompleted || wp.FailedAt == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Solo se pueden reprogramar paradas con intento fallido: " + wp.Address})
+			return
+		}
+		if i == 0 {
+			fleetID = wp.Route.CreatorID
+		} else if wp.Route.CreatorID != fleetID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Las paradas deben ser de la misma flota"})
+			return
+		}
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	// 2. Resolver destino
+	var target domains.Route
+	isNew := input.NewRoute != nil
+	if isNew {
+		if input.NewRoute.ScheduledDate.Before(today) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "La nueva ruta debe programarse para hoy o una fecha futura"})
+			return
+		}
+		name := input.NewRoute.Name
+		if name == "" {
+			name = "Reintentos " + input.NewRoute.ScheduledDate.Format("2006-01-02")
+		}
+		target = domains.Route{
+			ID:            uuid.New(),
+			CreatorID:     fleetID,
+			Name:          name,
+			Status:        "draft",
+			ScheduledDate: input.NewRoute.ScheduledDate,
+		}
+	} else {
+		if err := database.DB.Preload("Waypoints").First(&target, "id = ?", input.TargetRouteID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Ruta destino no encontrada"})
+			return
+		}
+		if !canManageRoute(user, &target) || target.CreatorID != fleetID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "No tienes permiso sobre la ruta destino"})
+			return
+		}
+		if !isEditableStatus(target.Status) || target.Status == "cancelled" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "La ruta destino ya salió, terminó o fue cancelada"})
+			return
+		}
+		if target.ScheduledDate == nil || target.ScheduledDate.Before(today) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "La ruta destino debe estar programada para hoy o una fecha futura"})
+			return
+		}
+		for _, wp := range stops {
+			if wp.RouteID == target.ID {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "La parada ya pertenece a la ruta destino"})
+				return
+			}
+		}
+	}
+
+	// 3. Las paradas se agregan al final de la ruta destino
+	next := 1
+	for _, wp := range target.Waypoints {
+		if wp.SequenceOrder >= next {
+			next = wp.SequenceOrder + 1
+		}
+	}
+	moved := make([]domains.Waypoint, len(stops))
+	copy(moved, stops)
+	sourceRoutes := map[uuid.UUID]domains.Route{}
+	for i := range moved {
+		sourceRoutes[moved[i].RouteID] = moved[i].Route
+		moved[i].SequenceOrder = next + i
+	}
+
+	movedIDs := make([]string, 0, len(moved))
+	for _, wp := range moved {
+		movedIDs = append(movedIDs, wp.ID.String())
+	}
+	targetBefore := target
+
+	// 4. Guardar en Transacción
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		actor := audit.FromContext(c)
+
+		if isNew {
+			if err := tx.Omit("Waypoints").Create(&target).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := saveWaypointPlacement(tx, target.ID, moved); err != nil {
+			return err
+		}
+		// Ya no está pendiente de reprogramar (el historial queda en los intentos)
+		// y la visita del día fallido no cuenta para la nueva
+		updates := dwell.ResetColumns()
+		updates["failed_at"] = nil
+		updates["failure_reason"] = ""
+		if err := tx.Model(&domains.Waypoint{}).Where("id IN ?", ids).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		for i := range moved {
+			before := stops[i]
+			moved[i].FailedAt = nil
+			moved[i].FailureReason = ""
+			dwell.Reset(&moved[i])
+			entry := audit.WaypointEntry("reschedule", &target, &before, &moved[i])
+			if err := audit.Record(tx, actor, entry); err != nil {
+				return err
+			}
+		}
+
+		// Distancias: destino con las nuevas paradas, orígenes sin ellas
+		var targetStops []domains.Waypoint
+		if err := tx.Where("route_id = ?", target.ID).Find(&targetStops).Error; err != nil {
+			return err
+		}
+		target.TotalDistanceKm = routeDistance(targetStops)
+		if err := tx.Model(&domains.Route{}).Where("id = ?", target.ID).Updates(map[string]interface{}{
+			"total_distance_km": target.TotalDistanceKm,
+			"version":           gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return err
+		}
+		target.Version++
+		target.Waypoints = targetStops
+
+		for _, source := range sourceRoutes {
+			var remaining []domains.Waypoint
+			if err := tx.Where("route_id = ?", source.ID).Find(&remaining).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&domains.Route{}).Where("id = ?", source.ID).Updates(map[string]interface{}{
+				"total_distance_km": routeDistance(remaining),
+				"version":           gorm.Expr("version + 1"),
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		var routeEntry audit.Entry
+		if isNew {
+			if err := webhooks.EnqueueRouteCreated(tx, &target); err != nil {
+				return err
+			}
+			routeEntry = audit.RouteEntry("create", nil, &target)
+		} else {
+			routeEntry = audit.RouteEntry("reschedule_in", &targetBefore, &target)
+		}
+		routeEntry.Extra = map[string]audit.Change{"rescheduled_waypoints": {From: nil, To: movedIDs}}
+		return audit.Record(tx, actor, routeEntry)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error reprogramando paradas: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("%d paradas reprogramadas en %s", len(moved), target.Name),
+		"route":   target,
+	})
+}
@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/handlers/auditlog"
+)
+
+// GetRouteHistory devuelve la auditoría de la ruta y de sus paradas (más reciente primero)
+func GetRouteHistory(c *gin.Context) {
+	routeID := c.Param("id")
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	// Unscoped: el historial sigue disponible aunque la ruta esté en la papelera
+	var route domains.Route
+	if err := database.DB.Unscoped().First(&route, "id = ?", routeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ruta no encontrada"})
+		return
+	}
+
+	if !canManageRoute(user, &route) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "No tienes permiso para ver esta ruta"})
+		return
+	}
+
+	query := database.DB.Model(&domains.AuditLog{}).Where("route_id = ?", route.ID)
+	if entityType := c.Query("entity_type"); entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+
+	history, msg := auditlog.FetchPage(c, query)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
@@ -0,0 +1,242 @@
+package routes
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// sortColumns: campos permitidos en ?sort= y su expresión SQL.
+// scheduled_date puede ser NULL: lo normalizamos para que el cursor sea comparable.
+var sortColumns = map[string]string{
+	"scheduled_date": "COALESCE(routes.scheduled_date, '0001-01-01 00:00:00+00'::timestamptz)",
+	"created_at":     "routes.created_at",
+	"name":           "routes.name",
+}
+
+// listParams son los filtros, orden y paginación ya validados de ListRoutes
+type listParams struct {
+	Statuses []string
+	From     *time.Time
+	To       *time.Time // Exclusivo
+	DriverID *uuid.UUID
+	Search   string
+
+	SortField string
+	Desc      bool
+	Limit     int
+	Cursor    *listCursor
+	Summary   bool
+
+	// Paged: vino limit, cursor o view. Sin ellos se responde el array completo de siempre
+	// (los clientes anteriores a la paginación esperan un array, no {data, pagination})
+	Paged bool
+}
+
+// listCursor apunta a la última fila entregada (valor del campo de orden + ID como desempate)
+type listCursor struct {
+	Sort  string    `json:"s"`
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+// parseListParams lee la query string. Devuelve un mensaje de error no vacío si algo es inválido.
+func parseListParams(c *gin.Context) (*listParams, string) {
+	p := &listParams{
+		SortField: "created_at",
+		Desc:      true,
+		Limit:     defaultPageSize,
+	}
+
+	// Filtros
+	if raw := c.Query("status"); raw != "" {
+		for _, s := range strings.Split(raw, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				p.Statuses = append(p.Statuses, s)
+			}
+		}
+	}
+
+	if raw := c.Query("from"); raw != "" {
+		from, err := parseDateParam(raw)
+		if err != nil {
+			return nil, "Parámetro 'from' inválido (usa YYYY-MM-DD o RFC3339)"
+		}
+		p.From = &from
+	}
+
+	if raw := c.Query("to"); raw != "" {
+		to, err := parseDateParam(raw)
+		if err != nil {
+			return nil, "Parámetro 'to' inválido (usa YYYY-MM-DD o RFC3339)"
+		}
+		// Una fecha sin hora incluye el día completo
+		if len(raw) == len("2006-01-02") {
+			to = to.AddDate(0, 0, 1)
+		} else {
+			to = to.Add(time.Nanosecond)
+		}
+		p.To = &to
+	}
+
+	if raw := c.Query("driver_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, "Parámetro 'driver_id' inválido"
+		}
+		p.DriverID = &id
+	}
+
+	p.Search = strings.TrimSpace(c.Query("q"))
+
+	// Orden
+	if raw := c.Query("sort"); raw != "" {
+		if _, ok := sortColumns[raw]; !ok {
+			return nil, "Parámetro 'sort' inválido (scheduled_date, created_at, name)"
+		}
+		p.SortField = raw
+	}
+
+	switch c.DefaultQuery("order", "desc") {
+	case "asc":
+		p.Desc = false
+	case "desc":
+		p.Desc = true
+	default:
+		return nil, "Parámetro 'order' inválido (asc, desc)"
+	}
+
+	// Paginación
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return nil, "Parámetro 'limit' inválido"
+		}
+		if n > maxPageSize {
+			n = maxPageSize
+		}
+		p.Limit = n
+	}
+
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, err := decodeCursor(raw)
+		if err != nil || cursor.Sort != p.SortField {
+			return nil, "Cursor inválido o de otro orden"
+		}
+		p.Cursor = cursor
+	}
+
+	p.Summary = c.Query("view") == "summary"
+	p.Paged = c.Query("limit") != "" || c.Query("cursor") != "" || c.Query("view") != ""
+
+	return p, ""
+}
+
+// apply agrega filtros, keyset y orden a la query (no toca el scope por rol)
+func (p *listParams) apply(query *gorm.DB) *gorm.DB {
+	if len(p.Statuses) > 0 {
+		query = query.Where("routes.status IN ?", p.Statuses)
+	}
+	if p.From != nil {
+		query = query.Where("routes.scheduled_date >= ?", *p.From)
+	}
+	if p.To != nil {
+		query = query.Where("routes.scheduled_date < ?", *p.To)
+	}
+	if p.DriverID != nil {
+		query = query.Where("routes.driver_id = ?", *p.DriverID)
+	}
+	if p.Search != "" {
+		query = query.Where("routes.name ILIKE ?", "%"+escapeLike(p.Search)+"%")
+	}
+
+	column := sortColumns[p.SortField]
+	direction, op := "ASC", ">"
+	if p.Desc {
+		direction, op = "DESC", "<"
+	}
+
+	if p.Cursor != nil {
+		value := p.cursorValue()
+		query = query.Where(
+			fmt.Sprintf("(%s %s ?) OR (%s = ? AND routes.id %s ?)", column, op, column, op),
+			value, value, p.Cursor.ID,
+		)
+	}
+
+	query = query.Order(fmt.Sprintf("%s %s, routes.id %s", column, direction, direction))
+	if !p.Paged {
+		return query
+	}
+	// Pedimos una fila extra para saber si hay más páginas
+	return query.Limit(p.Limit + 1)
+}
+
+// cursorValue convierte el valor del cursor al tipo de la columna de orden
+func (p *listParams) cursorValue() interface{} {
+	if p.SortField == "name" {
+		return p.Cursor.Value
+	}
+	t, err := time.Parse(time.RFC3339Nano, p.Cursor.Value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// nextCursor arma el cursor a partir de la última ruta de la página
+func (p *listParams) nextCursor(id uuid.UUID, name string, createdAt time.Time, scheduled *time.Time) string {
+	cursor := listCursor{Sort: p.SortField, ID: id}
+
+	switch p.SortField {
+	case "name":
+		cursor.Value = name
+	case "scheduled_date":
+		value := time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC) // Igual que el COALESCE de sortColumns
+		if scheduled != nil {
+			value = *scheduled
+		}
+		cursor.Value = value.Format(time.RFC3339Nano)
+	default:
+		cursor.Value = createdAt.Format(time.RFC3339Nano)
+	}
+
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(raw string) (*listCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, err
+	}
+	var cursor listCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+func parseDateParam(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", raw)
+}
+
+// escapeLike evita que % y _ del usuario actúen como comodines
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,199 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/audit"
+	"github.com/tu-usuario/route-manager/api/services/optimization"
+)
+
+type MergeRoutesInput struct {
+	TargetID  string   `json:"target_id" binding:"required"`        // Ruta que sobrevive
+	SourceIDs []string `json:"source_ids" binding:"required,min=1"` // Rutas que se absorben (y se eliminan)
+	Name      string   `json:"name"`                                // Nuevo nombre (opcional)
+	Optimize  bool     `json:"optimize"`                            // Re-optimizar el orden final
+
+	// AllowMismatch: fusionar aunque los orígenes tengan otra fecha o conductor (quedan con los del destino)
+	AllowMismatch bool `json:"allow_mismatch"`
+}
+
+// MergeRoutes combina varias rutas livianas en una sola
+func MergeRoutes(c *gin.Context) {
+	var input MergeRoutesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	// 1. Cargar destino y orígenes validando permisos y estado
+	seen := map[string]bool{input.TargetID: true}
+	for _, id := range input.SourceIDs {
+		if seen[id] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Rutas repetidas en la solicitud"})
+			return
+		}
+		seen[id] = true
+	}
+
+	var target domains.Route
+	if err := database.DB.Preload("Waypoints").First(&target, "id = ?", input.TargetID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ruta destino no encontrada"})
+		return
+	}
+
+	sources := make([]domains.Route, 0, len(input.SourceIDs))
+	for _, id := range input.SourceIDs {
+		var source domains.Route
+		if err := database.DB.Preload("Waypoints").First(&source, "id = ?", id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Ruta no encontrada: " + id})
+			return
+		}
+		sources = append(sources, source)
+	}
+
+	for _, r := range append([]domains.Route{target}, sources...) {
+		if !canManageRoute(user, &r) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "No tienes permiso sobre la ruta " + r.ID.String()})
+			return
+		}
+		if !isEditableStatus(r.Status) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No se pueden fusionar rutas en curso o finalizadas: " + r.Name})
+			return
+		}
+		if r.CreatorID != target.CreatorID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Solo se pueden fusionar rutas de la misma flota"})
+			return
+		}
+	}
+
+	// Una ruta del lunes no debe terminar en silencio dentro de la del viernes de otro conductor
+	if !input.AllowMismatch {
+		for _, source := range sources {
+			sameDate := sameDay(source.ScheduledDate, target.ScheduledDate)
+			sameDriver := sameDriverID(source.DriverID, target.DriverID)
+			if !sameDate || !sameDriver {
+				c.JSON(http.StatusConflict, gin.H{
+					"error":       fmt.Sprintf("\"%s\" tiene otra fecha o conductor que la ruta destino (envía allow_mismatch para fusionarlas igual)", source.Name),
+					"code":        "ROUTES_MISMATCH",
+					"route_id":    source.ID,
+					"same_date":   sameDate,
+					"same_driver": sameDriver,
+				})
+				return
+			}
+		}
+	}
+
+	// 2. Armar la lista final: primero las del destino, luego cada origen en el orden recibido
+	merged := make([]domains.Waypoint, len(target.Waypoints))
+	copy(merged, target.Waypoints)
+	resequence(merged)
+
+	duration := target.EstimatedDurationMin
+	for _, source := range sources {
+		sourceWps := make([]domains.Waypoint, len(source.Waypoints))
+		copy(sourceWps, source.Waypoints)
+		resequence(sourceWps)
+
+		offset := len(merged)
+		for _, wp := range sourceWps {
+			wp.SequenceOrder += offset
+			merged = append(merged, wp)
+		}
+		duration += source.EstimatedDurationMin
+	}
+
+	// Misma regla que OptimizeRoute: mínimo 3 puntos
+	if input.Optimize && len(merged) >= 3 {
+		merged = optimization.OptimizeRoute(merged)
+		for i := range merged {
+			merged[i].SequenceOrder = i + 1
+		}
+	}
+
+	before := target
+	if input.Name != "" {
+		target.Name = input.Name
+	}
+	target.TotalDistanceKm = routeDistance(merged)
+	target.EstimatedDurationMin = duration
+
+	// 3. Guardar en Transacción
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := saveWaypointPlacement(tx, target.ID, merged); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&domains.Route{}).Where("id = ?", target.ID).Updates(map[string]interface{}{
+			"name":                   target.Name,
+			"total_distance_km":      target.TotalDistanceKm,
+			"estimated_duration_min": target.EstimatedDurationMin,
+			"version":                gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return err
+		}
+		target.Version++
+
+		// Las rutas absorbidas ya no tienen paradas: se eliminan
+		actor := audit.FromContext(c)
+		sourceIDs := make([]string, 0, len(sources))
+		for i := range sources {
+			if err := softDeleteRoute(tx, &sources[i]); err != nil {
+				return err
+			}
+
+			deleteEntry := audit.RouteEntry("delete", &sources[i], nil)
+			deleteEntry.Extra = map[string]audit.Change{"merged_into": {From: nil, To: target.ID}}
+			if err := audit.Record(tx, actor, deleteEntry); err != nil {
+				return err
+			}
+			sourceIDs = append(sourceIDs, sources[i].ID.String())
+		}
+
+		mergeEntry := audit.RouteEntry("merge", &before, &target)
+		mergeEntry.Extra = map[string]audit.Change{"merged_routes": {From: nil, To: sourceIDs}}
+		return audit.Record(tx, actor, mergeEntry)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fusionando rutas: " + err.Error()})
+		return
+	}
+
+	target.Waypoints = merged
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("%d rutas fusionadas en '%s' (%d paradas)", len(sources)+1, target.Name, len(merged)),
+		"route":   target,
+	})
+}
+
+// sameDay compara el día calendario (hora local) de dos fechas programadas; sin fecha solo coincide con sin fecha
+func sameDay(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	ay, am, ad := a.Local().Date()
+	by, bm, bd := b.Local().Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// sameDriverID: sin conductor solo coincide con sin conductor
+func sameDriverID(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
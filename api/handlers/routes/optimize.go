@@ -1,62 +1,102 @@
-package routes
-
-import (
-	"net/http"
-
-	"github.com/gin-gonic/gin"
-	"github.com/tu-usuario/route-manager/api/database"
-	"github.com/tu-usuario/route-manager/api/domains"
-	"github.com/tu-usuario/route-manager/api/services/optimization"
-)
-
-func OptimizeRoute(c *gin.Context) {
-	routeID := c.Param("id")
-
-	// 1. Obtener la ruta y sus waypoints
-	var route domains.Route
-	// Preload es importante para traer los waypoints
-	if err := database.DB.Preload("Waypoints").First(&route, "id = ?", routeID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Ruta no encontrada"})
-		return
-	}
-
-	if len(route.Waypoints) < 3 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Se necesitan al menos 3 puntos para optimizar"})
-		return
-	}
-
-	// 2. Ejecutar el Algoritmo
-	optimizedWaypoints := optimization.OptimizeRoute(route.Waypoints)
-
-	// 3. Actualizar el orden (SequenceOrder) en la base de datos
-	// GORM hace esto en una transacción para seguridad
-	tx := database.DB.Begin()
-
-	// Actualizamos distancia total estimada ya que estamos aquí
-	newTotalDist := optimization.CalculateRouteDistance(optimizedWaypoints)
-
-	// Actualizar cada waypoint con su nuevo orden
-	for i, wp := range optimizedWaypoints {
-		wp.SequenceOrder = i + 1 // Orden 1, 2, 3...
-		if err := tx.Model(&domains.Waypoint{}).Where("id = ?", wp.ID).Update("sequence_order", wp.SequenceOrder).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error guardando optimización"})
-			return
-		}
-	}
-
-	// Actualizar total km en la ruta
-	if err := tx.Model(&route).Update("total_distance_km", newTotalDist).Error; err != nil {
-		tx.Rollback()
-		return
-	}
-
-	tx.Commit()
-
-	c.JSON(http.StatusOK, gin.H{
-		"message":           "Ruta optimizada exitosamente",
-		"original_distance": route.TotalDistanceKm, // Distancia vieja (si existía)
-		"new_distance":      newTotalDist,
-		"optimized_order":   optimizedWaypoints,
-	})
-}
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/audit"
+	"github.com/tu-usuario/route-manager/api/services/optimization"
+	"github.com/tu-usuario/route-manager/api/utils"
+	"gorm.io/gorm"
+)
+
+func OptimizeRoute(c *gin.Context) {
+	routeID := c.Param("id")
+
+	// 1. Obtener la ruta y sus waypoints
+	var route domains.Route
+	// Preload es importante para traer los waypoints
+	if err := database.DB.Preload("Waypoints").First(&route, "id = ?", routeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ruta no encontrada"})
+		return
+	}
+
+	if !utils.CheckIfMatch(c, route.Version) {
+		return
+	}
+
+	if len(route.Waypoints) < 3 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Se necesitan al menos 3 puntos para optimizar"})
+		return
+	}
+
+	// 2. Ejecutar el Algoritmo
+	optimizedWaypoints := optimization.OptimizeRoute(route.Waypoints)
+
+	// 3. Actualizar el orden (SequenceOrder) en la base de datos
+	// GORM hace esto en una transacción para seguridad
+	tx := database.DB.Begin()
+
+	// Actualizamos distancia total estimada ya que estamos aquí
+	newTotalDist := optimization.CalculateRouteDistance(optimizedWaypoints)
+
+	// Actualizar cada waypoint con su nuevo orden
+	for i, wp := range optimizedWaypoints {
+		wp.SequenceOrder = i + 1 // Orden 1, 2, 3...
+		if err := tx.Model(&domains.Waypoint{}).Where("id = ?", wp.ID).Updates(map[string]interface{}{
+			"sequence_order": wp.SequenceOrder,
+			"version":        gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error guardando optimización"})
+			return
+		}
+	}
+
+	// Actualizar total km en la ruta
+	// (con guarda de versión: si alguien editó la ruta mientras optimizábamos, abortamos)
+	before := route
+	result := tx.Model(&domains.Route{}).
+		Where("id = ? AND version = ?", route.ID, route.Version).
+		Updates(map[string]interface{}{
+			"total_distance_km": newTotalDist,
+			"version":           gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error guardando optimización"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		respondRouteConflict(c, route.ID)
+		return
+	}
+	route.TotalDistanceKm = newTotalDist
+	route.Version++
+
+	// Auditoría: distancia + nuevo orden de paradas
+	order := make([]string, 0, len(optimizedWaypoints))
+	for _, wp := range optimizedWaypoints {
+		order = append(order, wp.ID.String())
+	}
+	entry := audit.RouteEntry("optimize", &before, &route)
+	entry.Extra = map[string]audit.Change{"waypoint_order": {From: nil, To: order}}
+	if err := audit.Record(tx, audit.FromContext(c), entry); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error registrando auditoría"})
+		return
+	}
+
+	tx.Commit()
+
+	c.Header("ETag", utils.ETag(route.Version))
+	c.JSON(http.StatusOK, gin.H{
+		"message":           "Ruta optimizada exitosamente",
+		"original_distance": route.TotalDistanceKm, // Distancia vieja (si existía)
+		"new_distance":      newTotalDist,
+		"optimized_order":   optimizedWaypoints,
+	})
+}
@@ -0,0 +1,81 @@
+package routes
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/audit"
+	"github.com/tu-usuario/route-manager/api/utils"
+)
+
+// softDeleteRoute manda la ruta y sus paradas a la papelera.
+// Ambas quedan con el mismo deleted_at: así al restaurar sabemos qué paradas cayeron con la ruta.
+func softDeleteRoute(tx *gorm.DB, route *domains.Route) error {
+	now := time.Now().Truncate(time.Microsecond) // Precisión de Postgres
+
+	if err := tx.Model(&domains.Waypoint{}).Where("route_id = ?", route.ID).Update("deleted_at", now).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&domains.Route{}).Where("id = ?", route.ID).Update("deleted_at", now).Error; err != nil {
+		return err
+	}
+
+	route.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+	return nil
+}
+
+// RestoreRoute saca una ruta de la papelera (con las paradas que se borraron con ella)
+func RestoreRoute(c *gin.Context) {
+	routeID := c.Param("id")
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var route domains.Route
+	if err := database.DB.Unscoped().First(&route, "id = ? AND deleted_at IS NOT NULL", routeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ruta no encontrada en la papelera"})
+		return
+	}
+
+	if !canManageRoute(user, &route) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "No tienes permiso sobre esta ruta"})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&domains.Waypoint{}).
+			Where("route_id = ? AND deleted_at = ?", route.ID, route.DeletedAt.Time).
+			Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Model(&domains.Route{}).Where("id = ?", route.ID).Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"version":    gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return err
+		}
+		route.DeletedAt = gorm.DeletedAt{}
+		route.Version++
+
+		return audit.Record(tx, audit.FromContext(c), audit.RouteEntry("restore", nil, &route))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error restaurando ruta"})
+		return
+	}
+
+	database.DB.Preload("Waypoints", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sequence_order ASC")
+	}).First(&route, "id = ?", route.ID)
+
+	c.Header("ETag", utils.ETag(route.Version))
+	c.JSON(http.StatusOK, route)
+}
@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/dwell"
+)
+
+// GetRouteServiceTimes devuelve llegada/salida/tiempo de servicio por parada y el resumen de la ruta
+func GetRouteServiceTimes(c *gin.Context) {
+	routeID := c.Param("id")
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var route domains.Route
+	if err := database.DB.Preload("Waypoints").First(&route, "id = ?", routeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ruta no encontrada"})
+		return
+	}
+
+	// Admin dueño, Super Admin o el conductor asignado
+	isDriver := route.DriverID != nil && *route.DriverID == user.ID
+	if !canManageRoute(user, &route) && !isDriver {
+		c.JSON(http.StatusForbidden, gin.H{"error": "No tienes permiso para ver esta ruta"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dwell.ForRoute(&route, route.Waypoints))
+}
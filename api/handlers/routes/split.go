@@ -0,0 +1,193 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/audit"
+	"github.com/tu-usuario/route-manager/api/services/webhooks"
+)
+
+// SplitRouteInput: se indica UNO de los dos criterios
+type SplitRouteInput struct {
+	AtSequence  int      `json:"at_sequence"`  // Las paradas con sequence_order >= at_sequence pasan a la nueva ruta
+	WaypointIDs []string `json:"waypoint_ids"` // O bien, la lista exacta de paradas a mover
+	Name        string   `json:"name"`         // Nombre de la nueva ruta (por defecto: "<nombre> (parte 2)")
+}
+
+// SplitRoute divide una ruta sobrecargada en dos
+func SplitRoute(c *gin.Context) {
+	routeID := c.Param("id")
+
+	var input SplitRouteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if (input.AtSequence > 0) == (len(input.WaypointIDs) > 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Debes indicar 'at_sequence' o 'waypoint_ids' (solo uno)"})
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	// 1. Buscar la ruta
+	var route domains.Route
+	if err := database.DB.Preload("Waypoints").First(&route, "id = ?", routeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ruta no encontrada"})
+		return
+	}
+
+	if !canManageRoute(user, &route) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "No tienes permiso sobre esta ruta"})
+		return
+	}
+
+	if !isEditableStatus(route.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No se puede dividir una ruta en curso o finalizada"})
+		return
+	}
+
+	// 2. Separar paradas que se quedan / que se mueven
+	selected := map[uuid.UUID]bool{}
+	for _, raw := range input.WaypointIDs {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID de parada inválido: " + raw})
+			return
+		}
+		selected[id] = true
+	}
+
+	var keep, move []domains.Waypoint
+	for _, wp := range route.Waypoints {
+		moves := selected[wp.ID]
+		if input.AtSequence > 0 {
+			moves = wp.SequenceOrder >= input.AtSequence
+		}
+		if moves {
+			move = append(move, wp)
+		} else {
+			keep = append(keep, wp)
+		}
+	}
+
+	if len(input.WaypointIDs) > 0 && len(move) != len(selected) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Algunas paradas no pertenecen a esta ruta"})
+		return
+	}
+	if len(keep) == 0 || len(move) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ambas rutas deben quedar con al menos una parada"})
+		return
+	}
+
+	resequence(keep)
+	resequence(move)
+
+	name := input.Name
+	if name == "" {
+		name = route.Name + " (parte 2)"
+	}
+
+	// La nueva ruta nace en borrador y sin conductor: hay que asignarla aparte
+	newRoute := domains.Route{
+		ID:            uuid.New(),
+		CreatorID:     route.CreatorID,
+		Name:          name,
+		Status:        "draft",
+		ScheduledDate: route.ScheduledDate,
+	}
+
+	movedIDs := make([]string, 0, len(move))
+	for _, wp := range move {
+		movedIDs = append(movedIDs, wp.ID.String())
+	}
+	before := route
+
+	// 3. Guardar en Transacción
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Omit: las paradas se mueven a mano (son filas existentes, no nuevas)
+		if err := tx.Omit("Waypoints").Create(&newRoute).Error; err != nil {
+			return err
+		}
+
+		if err := saveWaypointPlacement(tx, newRoute.ID, move); err != nil {
+			return err
+		}
+		if err := saveWaypointPlacement(tx, route.ID, keep); err != nil {
+			return err
+		}
+
+		newRoute.TotalDistanceKm = routeDistance(move)
+		route.TotalDistanceKm = routeDistance(keep)
+
+		if err := tx.Model(&newRoute).Update("total_distance_km", newRoute.TotalDistanceKm).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&domains.Route{}).Where("id = ?", route.ID).Updates(map[string]interface{}{
+			"total_distance_km": route.TotalDistanceKm,
+			"version":           gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return err
+		}
+		route.Version++
+
+		// Auditoría en ambas rutas: cuáles paradas salieron y hacia dónde
+		actor := audit.FromContext(c)
+		splitEntry := audit.RouteEntry("split", &before, &route)
+		splitEntry.Extra = map[string]audit.Change{
+			"moved_waypoints": {From: nil, To: movedIDs},
+			"split_into":      {From: nil, To: newRoute.ID},
+		}
+		if err := audit.Record(tx, actor, splitEntry); err != nil {
+			return err
+		}
+		if err := webhooks.EnqueueRouteCreated(tx, &newRoute); err != nil {
+			return err
+		}
+		createEntry := audit.RouteEntry("create", nil, &newRoute)
+		createEntry.Extra = map[string]audit.Change{"split_from": {From: nil, To: route.ID}}
+		return audit.Record(tx, actor, createEntry)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error dividiendo la ruta: " + err.Error()})
+		return
+	}
+
+	route.Waypoints = keep
+	newRoute.Waypoints = move
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  fmt.Sprintf("Ruta dividida: %d paradas se quedan, %d pasan a la nueva ruta", len(keep), len(move)),
+		"original": route,
+		"new":      newRoute,
+	})
+}
+
+// saveWaypointPlacement persiste la ruta y el orden de cada parada
+func saveWaypointPlacement(tx *gorm.DB, routeID uuid.UUID, waypoints []domains.Waypoint) error {
+	for i := range waypoints {
+		waypoints[i].RouteID = routeID
+		if err := tx.Model(&domains.Waypoint{}).
+			Where("id = ?", waypoints[i].ID).
+			Updates(map[string]interface{}{
+				"route_id":       routeID,
+				"sequence_order": waypoints[i].SequenceOrder,
+				"version":        gorm.Expr("version + 1"),
+			}).Error; err != nil {
+			return err
+		}
+		waypoints[i].Version++
+	}
+	return nil
+}
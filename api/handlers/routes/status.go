@@ -1,54 +1,102 @@
-package routes
-
-import (
-	"net/http"
-
-	"github.com/gin-gonic/gin"
-	"github.com/tu-usuario/route-manager/api/database"
-	"github.com/tu-usuario/route-manager/api/domains"
-)
-
-type UpdateStatusInput struct {
-	Status string `json:"status" binding:"required"` // in_progress, completed, cancelled
-}
-
-func UpdateRouteStatus(c *gin.Context) {
-	routeID := c.Param("id")
-	userID, _ := c.Get("userID") // ID del conductor logueado
-
-	var input UpdateStatusInput
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// 1. Buscar la ruta
-	var route domains.Route
-	if err := database.DB.First(&route, "id = ?", routeID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Ruta no encontrada"})
-		return
-	}
-
-	// 2. SEGURIDAD: Verificar que la ruta pertenece al conductor
-	if route.DriverID == nil || route.DriverID.String() != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "No tienes permiso para modificar esta ruta"})
-		return
-	}
-
-	// 3. Validar estado
-	validStatuses := map[string]bool{"pending": true, "in_progress": true, "completed": true, "cancelled": true}
-	if !validStatuses[input.Status] {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Estado inválido"})
-		return
-	}
-
-	// 4. Actualizar
-	route.Status = input.Status
-
-	if err := database.DB.Save(&route).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error actualizando estado"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Estado actualizado", "status": route.Status})
-}
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/audit"
+	"github.com/tu-usuario/route-manager/api/services/inspection"
+	"github.com/tu-usuario/route-manager/api/services/notify"
+	"github.com/tu-usuario/route-manager/api/services/realtime"
+	"github.com/tu-usuario/route-manager/api/services/webhooks"
+	"github.com/tu-usuario/route-manager/api/utils"
+)
+
+type UpdateStatusInput struct {
+	Status string `json:"status" binding:"required"` // in_progress, completed, cancelled
+}
+
+func UpdateRouteStatus(c *gin.Context) {
+	routeID := c.Param("id")
+	userID, _ := c.Get("userID") // ID del conductor logueado
+
+	var input UpdateStatusInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 1. Buscar la ruta
+	var route domains.Route
+	if err := database.DB.First(&route, "id = ?", routeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ruta no encontrada"})
+		return
+	}
+
+	// 2. SEGURIDAD: Verificar que la ruta pertenece al conductor
+	if route.DriverID == nil || route.DriverID.String() != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "No tienes permiso para modificar esta ruta"})
+		return
+	}
+
+	if !utils.CheckIfMatch(c, route.Version) {
+		return
+	}
+
+	// 3. Validar estado
+	validStatuses := map[string]bool{"pending": true, "in_progress": true, "completed": true, "cancelled": true}
+	if !validStatuses[input.Status] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Estado inválido"})
+		return
+	}
+
+	// 3.1 Inspección pre-viaje: con críticos fallidos (o sin checklist, si la flota lo exige) no se sale
+	if input.Status == "in_progress" && route.Status != "in_progress" && route.Status != "completed" {
+		code, reason, err := inspection.StartBlocker(&route)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error evaluando inspección pre-viaje"})
+			return
+		}
+		if code != "" {
+			c.JSON(http.StatusConflict, gin.H{"error": reason, "code": code})
+			return
+		}
+	}
+
+	// 4. Actualizar
+	before := route
+	route.Status = input.Status
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := database.SaveVersioned(tx, &route); err != nil {
+			return err
+		}
+		if err := webhooks.EnqueueRouteStatusChanged(tx, &route, before.Status); err != nil {
+			return err
+		}
+		if route.Status == "in_progress" && before.Status != "in_progress" {
+			if err := notify.EnqueueRouteStarted(tx, &route); err != nil {
+				return err
+			}
+		}
+		return audit.Record(tx, audit.FromContext(c), audit.RouteEntry("status", &before, &route))
+	})
+	if err != nil {
+		if errors.Is(err, database.ErrVersionConflict) {
+			respondRouteConflict(c, route.ID)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error actualizando estado"})
+		return
+	}
+
+	realtime.Publish(realtime.RouteEvent(realtime.RouteStatus, &route, gin.H{"status": route.Status}))
+
+	c.Header("ETag", utils.ETag(route.Version))
+	c.JSON(http.StatusOK, gin.H{"message": "Estado actualizado", "status": route.Status})
+}
@@ -0,0 +1,295 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/audit"
+	"github.com/tu-usuario/route-manager/api/services/dwell"
+	"github.com/tu-usuario/route-manager/api/services/fleet"
+	"github.com/tu-usuario/route-manager/api/services/realtime"
+	"github.com/tu-usuario/route-manager/api/services/tracking"
+)
+
+const maxTrackPointsPerBatch = 500
+
+// TrackPointInput es una lectura GPS del dispositivo (se envían en lotes)
+type TrackPointInput struct {
+	Latitude  *float64  `json:"lat" binding:"required"`
+	Longitude *float64  `json:"lng" binding:"required"`
+	Accuracy  *float64  `json:"accuracy"`
+	Speed     *float64  `json:"speed"`
+	Heading   *float64  `json:"heading"`
+	Timestamp time.Time `json:"timestamp" binding:"required"`
+}
+
+type TrackBatchInput struct {
+	Points []TrackPointInput `json:"points" binding:"required,min=1,dive"`
+}
+
+// InferredEvent es una llegada/salida deducida de las posiciones recibidas
+type InferredEvent struct {
+	WaypointID uuid.UUID `json:"waypoint_id"`
+	Event      string    `json:"event"` // arrive, depart
+	At         time.Time `json:"at"`
+}
+
+// RecordTrackPoints (Conductor) recibe un lote de posiciones de su ruta en curso.
+// Guarda solo los puntos que aportan al recorrido y deduce llegadas/salidas de las paradas.
+func RecordTrackPoints(c *gin.Context) {
+	routeID := c.Param("id")
+	userID, _ := c.Get("userID")
+
+	var input TrackBatchInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(input.Points) > maxTrackPointsPerBatch {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Máximo %d puntos por lote", maxTrackPointsPerBatch)})
+		return
+	}
+
+	// 1. Buscar ruta y validar que sea del conductor y esté en curso
+	var route domains.Route
+	if err := database.DB.Preload("Waypoints").First(&route, "id = ?", routeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ruta no encontrada"})
+		return
+	}
+	if route.DriverID == nil || route.DriverID.String() != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Sin permiso"})
+		return
+	}
+	if route.Status != "in_progress" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La ruta no está en curso"})
+		return
+	}
+
+	// 2. Validar puntos
+	now := time.Now()
+	points := make([]domains.Breadcrumb, 0, len(input.Points))
+	for i, p := range input.Points {
+		if *p.Latitude < -90 || *p.Latitude > 90 || *p.Longitude < -180 || *p.Longitude > 180 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Punto %d: coordenadas inválidas", i)})
+			return
+		}
+		if p.Timestamp.After(now.Add(5 * time.Minute)) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Punto %d: timestamp en el futuro", i)})
+			return
+		}
+		points = append(points, domains.Breadcrumb{
+			RouteID:    route.ID,
+			DriverID:   *route.DriverID,
+			Latitude:   *p.Latitude,
+			Longitude:  *p.Longitude,
+			AccuracyM:  p.Accuracy,
+			SpeedMps:   p.Speed,
+			HeadingDeg: p.Heading,
+			RecordedAt: p.Timestamp,
+		})
+	}
+
+	// 3. Reducir contra el último punto guardado
+	var last *domains.Breadcrumb
+	var lastStored domains.Breadcrumb
+	if err := database.DB.Where("route_id = ?", route.ID).Order("recorded_at DESC").First(&lastStored).Error; err == nil {
+		last = &lastStored
+	}
+	kept := tracking.Downsample(last, points)
+
+	// 4. Llegadas/salidas inferidas: con todos los puntos nuevos, no solo los guardados.
+	// La app puede mandarlos desordenados (reintentos offline) y la inferencia los recorre en orden.
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].RecordedAt.Before(points[j].RecordedAt)
+	})
+	policy, err := fleet.Settings(route.CreatorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo política de la flota"})
+		return
+	}
+	events, before := inferPresence(route.Waypoints, points, last, &policy)
+
+	// 5. Guardar
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if len(kept) > 0 {
+			if err := tx.CreateInBatches(kept, 100).Error; err != nil {
+				return err
+			}
+		}
+
+		actor := audit.FromContext(c)
+		for i := range route.Waypoints {
+			wp := &route.Waypoints[i]
+			prev, changed := before[wp.ID]
+			if !changed {
+				continue
+			}
+			if err := database.SaveVersioned(tx, wp); err != nil {
+				if errors.Is(err, database.ErrVersionConflict) {
+					// Alguien la modificó mientras tanto: la inferencia no pisa cambios manuales
+					log.Printf("Inferencia de llegada/salida descartada para parada %s: versión desactualizada", wp.ID)
+					continue
+				}
+				return err
+			}
+			action := "arrive"
+			if wp.DepartedAt != nil {
+				action = "depart"
+			}
+			if err := audit.Record(tx, actor, audit.WaypointEntry(action, &route, &prev, wp)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error guardando posiciones"})
+		return
+	}
+
+	if len(kept) > 0 {
+		realtime.Publish(realtime.RouteEvent(realtime.DriverPosition, &route, gin.H{
+			"position": kept[len(kept)-1],
+			"inferred": events,
+		}))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"received": len(input.Points),
+		"stored":   len(kept),
+		"inferred": events,
+	})
+}
+
+// inferPresence aplica las posiciones sobre las paradas de la ruta y devuelve los eventos
+// deducidos junto con el estado previo de cada parada modificada
+func inferPresence(waypoints []domains.Waypoint, points []domains.Breadcrumb, last *domains.Breadcrumb, policy *domains.FleetSettings) ([]InferredEvent, map[uuid.UUID]domains.Waypoint) {
+	events := []InferredEvent{}
+	before := map[uuid.UUID]domains.Waypoint{}
+
+	for _, p := range points {
+		if last != nil && !p.RecordedAt.After(last.RecordedAt) {
+			continue
+		}
+		// Lecturas imprecisas no sirven para decidir si el conductor está en la parada
+		if p.AccuracyM != nil && *p.AccuracyM > float64(policy.GeofenceMaxAccuracyM) {
+			continue
+		}
+
+		for i := range waypoints {
+			wp := &waypoints[i]
+			// Cerrada sin registrar llegada: no inventamos una llegada posterior
+			if (wp.IsCompleted || wp.FailedAt != nil) && wp.ArrivedAt == nil {
+				continue
+			}
+
+			snapshot := *wp
+			event := dwell.Infer(wp, p.Latitude, p.Longitude, p.RecordedAt, policy.GeofenceAcceptRadiusM)
+			if event == "" {
+				continue
+			}
+			if _, seen := before[wp.ID]; !seen {
+				before[wp.ID] = snapshot
+			}
+			events = append(events, InferredEvent{WaypointID: wp.ID, Event: event, At: p.RecordedAt})
+		}
+	}
+
+	return events, before
+}
+
+// PlannedStop es una parada del plan, para dibujarla junto al recorrido real
+type PlannedStop struct {
+	ID            uuid.UUID  `json:"id"`
+	SequenceOrder int        `json:"sequence_order"`
+	Address       string     `json:"address"`
+	Latitude      float64    `json:"latitude"`
+	Longitude     float64    `json:"longitude"`
+	IsCompleted   bool       `json:"is_completed"`
+	ArrivedAt     *time.Time `json:"arrived_at,omitempty"`
+	DepartedAt    *time.Time `json:"departed_at,omitempty"`
+}
+
+// RouteTrack es el recorrido real de la ruta comparado con el planificado
+type RouteTrack struct {
+	RouteID           uuid.UUID            `json:"route_id"`
+	Status            string               `json:"status"`
+	Planned           []PlannedStop        `json:"planned"`
+	Path              []domains.Breadcrumb `json:"path"`
+	LastPosition      *domains.Breadcrumb  `json:"last_position"`
+	PlannedDistanceKm float64              `json:"planned_distance_km"`
+	ActualDistanceKm  float64              `json:"actual_distance_km"`
+	DeviationPct      *float64             `json:"deviation_pct"` // (real - plan) / plan; nil si no hay plan
+}
+
+// GetRouteTrack (Admin) devuelve el recorrido real del conductor junto a las paradas planificadas
+func GetRouteTrack(c *gin.Context) {
+	routeID := c.Param("id")
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var route domains.Route
+	if err := database.DB.Preload("Waypoints").First(&route, "id = ?", routeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ruta no encontrada"})
+		return
+	}
+
+	if !canManageRoute(user, &route) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "No tienes permiso para ver esta ruta"})
+		return
+	}
+
+	var path []domains.Breadcrumb
+	if err := database.DB.Where("route_id = ?", route.ID).Order("recorded_at ASC").Find(&path).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo recorrido"})
+		return
+	}
+
+	sort.SliceStable(route.Waypoints, func(i, j int) bool {
+		return route.Waypoints[i].SequenceOrder < route.Waypoints[j].SequenceOrder
+	})
+	planned := make([]PlannedStop, 0, len(route.Waypoints))
+	for _, wp := range route.Waypoints {
+		planned = append(planned, PlannedStop{
+			ID:            wp.ID,
+			SequenceOrder: wp.SequenceOrder,
+			Address:       wp.Address,
+			Latitude:      wp.Latitude,
+			Longitude:     wp.Longitude,
+			IsCompleted:   wp.IsCompleted,
+			ArrivedAt:     wp.ArrivedAt,
+			DepartedAt:    wp.DepartedAt,
+		})
+	}
+
+	track := RouteTrack{
+		RouteID:           route.ID,
+		Status:            route.Status,
+		Planned:           planned,
+		Path:              path,
+		PlannedDistanceKm: routeDistance(route.Waypoints),
+		ActualDistanceKm:  tracking.PathDistanceKm(path),
+	}
+	if len(path) > 0 {
+		track.LastPosition = &path[len(path)-1]
+	}
+	if track.PlannedDistanceKm > 0 {
+		deviation := (track.ActualDistanceKm - track.PlannedDistanceKm) / track.PlannedDistanceKm * 100
+		track.DeviationPct = &deviation
+	}
+
+	c.JSON(http.StatusOK, track)
+}
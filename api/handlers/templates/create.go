@@ -0,0 +1,173 @@
+package templates
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/recurrence"
+)
+
+// TemplateWaypointDTO: Parada "molde" que viene dentro del array de waypoints
+type TemplateWaypointDTO struct {
+	Address       string  `json:"address" binding:"required"`
+	Latitude      float64 `json:"latitude" binding:"required"`
+	Longitude     float64 `json:"longitude" binding:"required"`
+	SequenceOrder int     `json:"sequence_order" binding:"required"`
+	CustomerName  string  `json:"customer_name"`
+	CustomerEmail string  `json:"customer_email" binding:"omitempty,email"`
+	CustomerPhone string  `json:"customer_phone" binding:"omitempty,e164"`
+	Notes         string  `json:"notes"`
+}
+
+// CreateTemplateInput: JSON para crear una plantilla recurrente
+type CreateTemplateInput struct {
+	Name                 string                `json:"name" binding:"required"`
+	StartsAt             time.Time             `json:"starts_at" binding:"required"` // DTSTART (fecha + hora de salida)
+	RRule                string                `json:"rrule" binding:"required"`     // ej: FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR
+	DaysAhead            int                   `json:"days_ahead"`
+	TargetStatus         string                `json:"target_status"` // draft (defecto) o pending
+	DefaultDriverID      *string               `json:"default_driver_id"`
+	AutoOptimize         bool                  `json:"auto_optimize"`
+	TotalDistanceKm      float64               `json:"total_distance_km"`
+	EstimatedDurationMin int                   `json:"estimated_duration_min"`
+	Waypoints            []TemplateWaypointDTO `json:"waypoints" binding:"required,min=1"`
+}
+
+// maxDaysAhead evita que una plantilla genere meses de rutas de golpe
+const maxDaysAhead = 60
+
+func CreateTemplate(c *gin.Context) {
+	// 1. Obtener ID del Creador (Admin) del contexto
+	creatorIDStr, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+	creatorUUID, err := uuid.Parse(creatorIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de usuario inválido"})
+		return
+	}
+
+	// 2. Validar JSON
+	var input CreateTemplateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	// 3. Validar regla y parámetros de materialización
+	if _, err := recurrence.Parse(input.RRule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "RRULE inválida: " + err.Error()})
+		return
+	}
+
+	daysAhead, targetStatus, msg := validateMaterialization(input.DaysAhead, input.TargetStatus)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	defaultDriverID, msg := resolveDefaultDriver(input.DefaultDriverID, creatorUUID)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	// 4. Mapear DTO a Entidades de Dominio
+	var templateWaypoints []domains.RouteTemplateWaypoint
+	for _, wp := range input.Waypoints {
+		templateWaypoints = append(templateWaypoints, domains.RouteTemplateWaypoint{
+			ID:            uuid.New(),
+			Address:       wp.Address,
+			Latitude:      wp.Latitude,
+			Longitude:     wp.Longitude,
+			SequenceOrder: wp.SequenceOrder,
+			CustomerName:  wp.CustomerName,
+			CustomerEmail: wp.CustomerEmail,
+			CustomerPhone: wp.CustomerPhone,
+			Notes:         wp.Notes,
+		})
+	}
+
+	template := domains.RouteTemplate{
+		ID:                   uuid.New(),
+		CreatorID:            creatorUUID,
+		Name:                 input.Name,
+		StartsAt:             input.StartsAt,
+		RRule:                input.RRule,
+		DaysAhead:            daysAhead,
+		TargetStatus:         targetStatus,
+		DefaultDriverID:      defaultDriverID,
+		AutoOptimize:         input.AutoOptimize,
+		IsActive:             true,
+		TotalDistanceKm:      input.TotalDistanceKm,
+		EstimatedDurationMin: input.EstimatedDurationMin,
+		Waypoints:            templateWaypoints,
+	}
+
+	// 5. Guardar (GORM inserta plantilla + paradas en una transacción)
+	if err := database.DB.Create(&template).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo crear la plantilla: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  fmt.Sprintf("Plantilla creada con %d paradas", len(templateWaypoints)),
+		"template": template,
+	})
+}
+
+// validateMaterialization normaliza days_ahead y target_status.
+// Devuelve un mensaje de error no vacío si los valores no son válidos.
+func validateMaterialization(daysAhead int, targetStatus string) (int, string, string) {
+	if daysAhead == 0 {
+		daysAhead = 7
+	}
+	if daysAhead < 1 || daysAhead > maxDaysAhead {
+		return 0, "", fmt.Sprintf("days_ahead debe estar entre 1 y %d", maxDaysAhead)
+	}
+
+	if targetStatus == "" {
+		targetStatus = "draft"
+	}
+	if targetStatus != "draft" && targetStatus != "pending" {
+		return 0, "", "target_status debe ser 'draft' o 'pending'"
+	}
+
+	return daysAhead, targetStatus, ""
+}
+
+// resolveDefaultDriver valida que el conductor por defecto pertenezca a la flota del creador
+func resolveDefaultDriver(rawID *string, creatorID uuid.UUID) (*uuid.UUID, string) {
+	if rawID == nil || *rawID == "" {
+		return nil, ""
+	}
+
+	driverUUID, err := uuid.Parse(*rawID)
+	if err != nil {
+		return nil, "ID de conductor inválido"
+	}
+
+	var creator domains.User
+	if err := database.DB.Select("id, role").First(&creator, "id = ?", creatorID).Error; err != nil {
+		return nil, "Usuario no encontrado"
+	}
+
+	query := database.DB.Where("id = ? AND role = 'driver'", driverUUID)
+	if creator.Role != "super_admin" {
+		query = query.Where("manager_id = ?", creatorID)
+	}
+
+	var driver domains.User
+	if err := query.First(&driver).Error; err != nil {
+		return nil, "El conductor no existe o no pertenece a tu flota"
+	}
+
+	return &driverUUID, ""
+}
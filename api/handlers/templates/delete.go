@@ -0,0 +1,43 @@
+package templates
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+)
+
+// DeleteTemplate elimina la plantilla y sus paradas.
+// Las rutas ya generadas se conservan: quedan como rutas sueltas, sin plantilla de origen.
+func DeleteTemplate(c *gin.Context) {
+	template, ok := findOwnedTemplate(c)
+	if !ok {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("template_id = ?", template.ID).Delete(&domains.RouteTemplateWaypoint{}).Error; err != nil {
+			return err
+		}
+		// Unscoped: también las que están en la papelera
+		if err := tx.Unscoped().Model(&domains.Route{}).
+			Where("template_id = ?", template.ID).
+			Updates(map[string]interface{}{
+				"template_id":   nil,
+				"template_date": nil,
+				"version":       gorm.Expr("version + 1"),
+			}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&domains.RouteTemplate{}, "id = ?", template.ID).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error eliminando plantilla"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Plantilla eliminada correctamente"})
+}
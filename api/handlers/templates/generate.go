@@ -0,0 +1,36 @@
+package templates
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tu-usuario/route-manager/api/services/audit"
+	"github.com/tu-usuario/route-manager/api/services/scheduler"
+)
+
+// GenerateFromTemplate fuerza la materialización inmediata de la plantilla
+// (lo mismo que hace el scheduler en segundo plano, sin esperar al próximo ciclo)
+func GenerateFromTemplate(c *gin.Context) {
+	template, ok := findOwnedTemplate(c)
+	if !ok {
+		return
+	}
+
+	if !template.IsActive {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La plantilla está pausada"})
+		return
+	}
+
+	created, err := scheduler.MaterializeTemplate(template, time.Now(), audit.FromContext(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generando rutas: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("%d rutas generadas", len(created)),
+		"routes":  created,
+	})
+}
@@ -0,0 +1,69 @@
+package templates
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+)
+
+// ListTemplates lista las plantillas del admin (Super Admin ve todas)
+func ListTemplates(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var user domains.User
+	if err := database.DB.Select("id, role").First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no encontrado"})
+		return
+	}
+
+	query := database.DB.Preload("Waypoints").Preload("DefaultDriver").Order("created_at DESC")
+	if user.Role != "super_admin" {
+		query = query.Where("creator_id = ?", user.ID)
+	}
+
+	var templates []domains.RouteTemplate
+	if err := query.Find(&templates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error listando plantillas"})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// GetTemplate devuelve el detalle de una plantilla
+func GetTemplate(c *gin.Context) {
+	template, ok := findOwnedTemplate(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// findOwnedTemplate busca la plantilla de :id con sus paradas y verifica que
+// pertenezca al usuario (o que sea super_admin). Si falla, ya respondió al cliente.
+func findOwnedTemplate(c *gin.Context) (*domains.RouteTemplate, bool) {
+	templateID := c.Param("id")
+	userID, _ := c.Get("userID")
+
+	var template domains.RouteTemplate
+	if err := database.DB.Preload("Waypoints").Preload("DefaultDriver").First(&template, "id = ?", templateID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Plantilla no encontrada"})
+		return nil, false
+	}
+
+	var user domains.User
+	if err := database.DB.Select("id, role").First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario inválido"})
+		return nil, false
+	}
+
+	if user.Role != "super_admin" && template.CreatorID != user.ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "No tienes permiso sobre esta plantilla"})
+		return nil, false
+	}
+
+	return &template, true
+}
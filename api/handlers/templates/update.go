@@ -0,0 +1,147 @@
+package templates
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/recurrence"
+)
+
+// UpdateTemplateInput: todos los campos son opcionales.
+// Si viene "waypoints", reemplaza por completo las paradas de la plantilla.
+type UpdateTemplateInput struct {
+	Name                 string                `json:"name"`
+	StartsAt             *time.Time            `json:"starts_at"`
+	RRule                string                `json:"rrule"`
+	DaysAhead            int                   `json:"days_ahead"`
+	TargetStatus         string                `json:"target_status"`
+	DefaultDriverID      *string               `json:"default_driver_id"` // "" para quitar el conductor
+	AutoOptimize         *bool                 `json:"auto_optimize"`
+	IsActive             *bool                 `json:"is_active"`
+	TotalDistanceKm      float64               `json:"total_distance_km"`
+	EstimatedDurationMin int                   `json:"estimated_duration_min"`
+	Waypoints            []TemplateWaypointDTO `json:"waypoints" binding:"omitempty,dive"`
+}
+
+// UpdateTemplate modifica la plantilla. Solo afecta a las rutas que se generen
+// a partir de ahora: las ya materializadas no se tocan.
+func UpdateTemplate(c *gin.Context) {
+	// 1. Validar Body
+	var input UpdateTemplateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// "waypoints": [] dejaría la plantilla sin paradas (al crear se exige al menos una)
+	if input.Waypoints != nil && len(input.Waypoints) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La plantilla debe tener al menos una parada"})
+		return
+	}
+
+	// 2. Buscar plantilla (con permisos)
+	template, ok := findOwnedTemplate(c)
+	if !ok {
+		return
+	}
+
+	// 3. Actualizar campos (si vienen en el JSON)
+	if input.Name != "" {
+		template.Name = input.Name
+	}
+	if input.StartsAt != nil {
+		template.StartsAt = *input.StartsAt
+	}
+	if input.RRule != "" {
+		if _, err := recurrence.Parse(input.RRule); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "RRULE inválida: " + err.Error()})
+			return
+		}
+		template.RRule = input.RRule
+	}
+	if input.DaysAhead != 0 || input.TargetStatus != "" {
+		daysAhead := input.DaysAhead
+		if daysAhead == 0 {
+			daysAhead = template.DaysAhead
+		}
+		targetStatus := input.TargetStatus
+		if targetStatus == "" {
+			targetStatus = template.TargetStatus
+		}
+
+		normDays, normStatus, msg := validateMaterialization(daysAhead, targetStatus)
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+		template.DaysAhead = normDays
+		template.TargetStatus = normStatus
+	}
+	if input.DefaultDriverID != nil {
+		driverID, msg := resolveDefaultDriver(input.DefaultDriverID, template.CreatorID)
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+		template.DefaultDriverID = driverID
+		template.DefaultDriver = nil
+	}
+	if input.AutoOptimize != nil {
+		template.AutoOptimize = *input.AutoOptimize
+	}
+	if input.IsActive != nil {
+		template.IsActive = *input.IsActive
+	}
+	if input.TotalDistanceKm != 0 {
+		template.TotalDistanceKm = input.TotalDistanceKm
+	}
+	if input.EstimatedDurationMin != 0 {
+		template.EstimatedDurationMin = input.EstimatedDurationMin
+	}
+
+	// 4. Guardar en transacción (plantilla + reemplazo de paradas)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if input.Waypoints != nil {
+			if err := tx.Where("template_id = ?", template.ID).Delete(&domains.RouteTemplateWaypoint{}).Error; err != nil {
+				return err
+			}
+
+			newWaypoints := make([]domains.RouteTemplateWaypoint, 0, len(input.Waypoints))
+			for _, wp := range input.Waypoints {
+				newWaypoints = append(newWaypoints, domains.RouteTemplateWaypoint{
+					ID:            uuid.New(),
+					TemplateID:    template.ID,
+					Address:       wp.Address,
+					Latitude:      wp.Latitude,
+					Longitude:     wp.Longitude,
+					SequenceOrder: wp.SequenceOrder,
+					CustomerName:  wp.CustomerName,
+					CustomerEmail: wp.CustomerEmail,
+					CustomerPhone: wp.CustomerPhone,
+					Notes:         wp.Notes,
+				})
+			}
+			if len(newWaypoints) > 0 {
+				if err := tx.Create(&newWaypoints).Error; err != nil {
+					return err
+				}
+			}
+			template.Waypoints = newWaypoints
+		}
+
+		// Omit: las paradas ya se gestionaron arriba
+		return tx.Omit("Waypoints", "DefaultDriver").Save(template).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar plantilla"})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
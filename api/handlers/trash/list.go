@@ -0,0 +1,92 @@
+package trash
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/scheduler"
+)
+
+// TrashItem es un elemento borrado (las entidades ocultan deleted_at en su JSON)
+type TrashItem struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Detail    string    `json:"detail,omitempty"`
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"` // A partir de aquí ya no se puede restaurar
+}
+
+// ListTrash devuelve las rutas y usuarios borrados que todavía se pueden restaurar.
+// ?type=routes|users para pedir solo uno de los dos.
+func ListTrash(c *gin.Context) {
+	requestingUserID, _ := c.Get("userID")
+
+	var currentUser domains.User
+	database.DB.First(&currentUser, "id = ?", requestingUserID)
+
+	itemType := c.Query("type")
+	if itemType != "" && itemType != "routes" && itemType != "users" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type debe ser routes o users"})
+		return
+	}
+
+	retention := scheduler.TrashRetention()
+	response := gin.H{"retention_days": int(retention.Hours() / 24)}
+
+	// 1. Rutas (Admin: las que creó; Super Admin: todas)
+	if itemType == "" || itemType == "routes" {
+		var deletedRoutes []domains.Route
+		query := database.DB.Unscoped().Where("deleted_at IS NOT NULL")
+		if currentUser.Role != "super_admin" {
+			query = query.Where("creator_id = ?", currentUser.ID)
+		}
+		if err := query.Order("deleted_at DESC").Find(&deletedRoutes).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo papelera"})
+			return
+		}
+
+		items := make([]TrashItem, 0, len(deletedRoutes))
+		for _, r := range deletedRoutes {
+			items = append(items, TrashItem{
+				ID:        r.ID,
+				Name:      r.Name,
+				Detail:    r.Status,
+				DeletedAt: r.DeletedAt.Time,
+				PurgeAt:   r.DeletedAt.Time.Add(retention),
+			})
+		}
+		response["routes"] = items
+	}
+
+	// 2. Usuarios (Admin: sus conductores; Super Admin: todos). Los purgados ya no aparecen.
+	if itemType == "" || itemType == "users" {
+		var deletedUsers []domains.User
+		query := database.DB.Unscoped().Where("deleted_at IS NOT NULL AND purged_at IS NULL")
+		if currentUser.Role != "super_admin" {
+			query = query.Where("manager_id = ?", currentUser.ID)
+		}
+		if err := query.Order("deleted_at DESC").Find(&deletedUsers).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo papelera"})
+			return
+		}
+
+		items := make([]TrashItem, 0, len(deletedUsers))
+		for _, u := range deletedUsers {
+			items = append(items, TrashItem{
+				ID:        u.ID,
+				Name:      u.FullName,
+				Detail:    u.Email,
+				DeletedAt: u.DeletedAt.Time,
+				PurgeAt:   u.DeletedAt.Time.Add(retention),
+			})
+		}
+		response["users"] = items
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -1,28 +1,36 @@
-package users
-
-import (
-	"net/http"
-
-	"github.com/gin-gonic/gin"
-	"github.com/tu-usuario/route-manager/api/database"
-	"github.com/tu-usuario/route-manager/api/domains"
-)
-
-func DeleteUser(c *gin.Context) {
-	id := c.Param("id")
-
-	// Borrado lógico (Soft Delete)
-	result := database.DB.Delete(&domains.User{}, "id = ?", id)
-
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error eliminando usuario"})
-		return
-	}
-
-	if result.RowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Usuario eliminado correctamente"})
-}
+package users
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/audit"
+)
+
+func DeleteUser(c *gin.Context) {
+	id := c.Param("id")
+
+	var user domains.User
+	if err := database.DB.First(&user, "id = ?", id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		return
+	}
+
+	// Borrado lógico (Soft Delete): queda en la papelera hasta la purga
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&domains.User{}, "id = ?", user.ID).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, audit.FromContext(c), audit.UserEntry("delete", &user, nil))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error eliminando usuario"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Usuario enviado a la papelera"})
+}
@@ -0,0 +1,52 @@
+package users
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/audit"
+)
+
+// RestoreUser saca a un usuario de la papelera (si todavía no fue purgado)
+func RestoreUser(c *gin.Context) {
+	id := c.Param("id")
+	requestingUserID, _ := c.Get("userID")
+
+	var currentUser domains.User
+	database.DB.First(&currentUser, "id = ?", requestingUserID)
+
+	var user domains.User
+	if err := database.DB.Unscoped().First(&user, "id = ? AND deleted_at IS NOT NULL", id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado en la papelera"})
+		return
+	}
+
+	// Un Admin solo restaura a sus propios conductores
+	if currentUser.Role != "super_admin" && (user.ManagerID == nil || *user.ManagerID != currentUser.ID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "No tienes permiso sobre este usuario"})
+		return
+	}
+
+	if user.PurgedAt != nil {
+		c.JSON(http.StatusGone, gin.H{"error": "El usuario ya fue purgado y no puede restaurarse"})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&domains.User{}).Where("id = ?", user.ID).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		user.DeletedAt = gorm.DeletedAt{}
+		return audit.Record(tx, audit.FromContext(c), audit.UserEntry("restore", nil, &user))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error restaurando usuario"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
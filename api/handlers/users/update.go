@@ -1,59 +1,90 @@
-package users
-
-import (
-	"net/http"
-
-	"github.com/gin-gonic/gin"
-	"github.com/tu-usuario/route-manager/api/database"
-	"github.com/tu-usuario/route-manager/api/domains"
-	"github.com/tu-usuario/route-manager/api/utils"
-)
-
-// Input para actualizar
-type UpdateUserInput struct {
-	Role   string `json:"role"`
-	Status string `json:"status"`
-}
-
-func UpdateUser(c *gin.Context) {
-	targetID := c.Param("id")
-
-	var input UpdateUserInput
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	var user domains.User
-	if err := database.DB.First(&user, "id = ?", targetID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
-		return
-	}
-
-	// 2. Actualizar campos
-	if input.Status != "" {
-		user.Status = input.Status
-	}
-
-	if input.Role != "" {
-		user.Role = input.Role
-
-		// 1. Verificamos si es nil
-		if input.Role == "admin" && user.FleetCode == nil {
-
-			// 2. Generamos el código en una variable temporal
-			newCode := utils.GenerateFleetCode()
-
-			// 3. Asignamos la dirección de memoria (&) al puntero
-			user.FleetCode = &newCode
-		}
-	}
-
-	// 3. Guardar
-	if err := database.DB.Save(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar usuario"})
-		return
-	}
-
-	c.JSON(http.StatusOK, user)
-}
+package users
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/audit"
+	"github.com/tu-usuario/route-manager/api/utils"
+)
+
+// Input para actualizar
+type UpdateUserInput struct {
+	Role   string `json:"role"`
+	Status string `json:"status"`
+
+	// Datos operativos del conductor (asignación automática)
+	HomeLatitude    *float64 `json:"home_latitude"`
+	HomeLongitude   *float64 `json:"home_longitude"`
+	VehicleCapacity *int     `json:"vehicle_capacity"`
+}
+
+func UpdateUser(c *gin.Context) {
+	targetID := c.Param("id")
+
+	var input UpdateUserInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user domains.User
+	if err := database.DB.First(&user, "id = ?", targetID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		return
+	}
+
+	// 2. Actualizar campos
+	before := user
+	if input.Status != "" {
+		user.Status = input.Status
+	}
+
+	if input.Role != "" {
+		user.Role = input.Role
+
+		// 1. Verificamos si es nil
+		if input.Role == "admin" && user.FleetCode == nil {
+
+			// 2. Generamos el código en una variable temporal
+			newCode := utils.GenerateFleetCode()
+
+			// 3. Asignamos la dirección de memoria (&) al puntero
+			user.FleetCode = &newCode
+		}
+	}
+
+	if (input.HomeLatitude == nil) != (input.HomeLongitude == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "home_latitude y home_longitude van juntos"})
+		return
+	}
+	if input.HomeLatitude != nil {
+		user.HomeLatitude = input.HomeLatitude
+		user.HomeLongitude = input.HomeLongitude
+	}
+	if input.VehicleCapacity != nil {
+		if *input.VehicleCapacity < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "vehicle_capacity no puede ser negativo"})
+			return
+		}
+		user.VehicleCapacity = *input.VehicleCapacity
+	}
+
+	// 3. Guardar (junto con el registro de auditoría)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, audit.FromContext(c), audit.UserEntry("update", &before, &user))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar usuario"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
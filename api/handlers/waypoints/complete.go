@@ -1,84 +1,162 @@
-package waypoints
-
-import (
-	"net/http"
-	"time"
-
-	"github.com/gin-gonic/gin"
-	"github.com/tu-usuario/route-manager/api/database"
-	"github.com/tu-usuario/route-manager/api/domains"
-	"github.com/tu-usuario/route-manager/api/services/storage"
-)
-
-func MarkWaypointComplete(c *gin.Context) {
-	waypointID := c.Param("id")
-	userID, _ := c.Get("userID")
-
-	// 1. Buscar Waypoint
-	var wp domains.Waypoint
-	if err := database.DB.Preload("Route").First(&wp, "id = ?", waypointID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Punto no encontrado"})
-		return
-	}
-
-	// 2. Seguridad
-	if wp.Route.DriverID == nil || wp.Route.DriverID.String() != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Sin permiso"})
-		return
-	}
-
-	// 3. Procesar Archivo
-
-	// Form-data key: "proof_file"
-	fileHeader, err := c.FormFile("proof_file")
-	var storagePath string
-
-	if err == nil {
-		// A. Abrir archivo
-		file, openErr := fileHeader.Open()
-		if openErr != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Error abriendo archivo"})
-			return
-		}
-		defer file.Close()
-
-		// B. Inicializar Servicio Storage
-		storageSvc := storage.NewService()
-
-		// C. Subir a Supabase
-		contentType := fileHeader.Header.Get("Content-Type")
-		path, uploadErr := storageSvc.UploadFile(file, fileHeader.Filename, contentType)
-		if uploadErr != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error subiendo foto: " + uploadErr.Error()})
-			return
-		}
-		storagePath = path
-	}
-
-	// 4. Actualizar BD
-	now := time.Now()
-	wp.IsCompleted = true
-	wp.CompletedAt = &now
-
-	if storagePath != "" {
-		wp.ProofPhotoURL = &storagePath
-	}
-
-	if err := database.DB.Save(&wp).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error guardando cambios"})
-		return
-	}
-
-	// Para responder al front, firmamos la URL recién creada
-	var signedURL string
-	if storagePath != "" {
-		svc := storage.NewService()
-		signedURL, _ = svc.GetSignedURL(storagePath)
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message":          "Entrega completada",
-		"completed_at":     wp.CompletedAt,
-		"proof_signed_url": signedURL,
-	})
-}
+package waypoints
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/audit"
+	"github.com/tu-usuario/route-manager/api/services/dwell"
+	"github.com/tu-usuario/route-manager/api/services/fleet"
+	"github.com/tu-usuario/route-manager/api/services/geofence"
+	"github.com/tu-usuario/route-manager/api/services/notify"
+	"github.com/tu-usuario/route-manager/api/services/realtime"
+	"github.com/tu-usuario/route-manager/api/services/storage"
+	"github.com/tu-usuario/route-manager/api/services/webhooks"
+	"github.com/tu-usuario/route-manager/api/utils"
+)
+
+func MarkWaypointComplete(c *gin.Context) {
+	waypointID := c.Param("id")
+	userID, _ := c.Get("userID")
+
+	// 1. Buscar Waypoint
+	var wp domains.Waypoint
+	if err := database.DB.Preload("Route").First(&wp, "id = ?", waypointID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Punto no encontrado"})
+		return
+	}
+
+	// 2. Seguridad
+	if wp.Route.DriverID == nil || wp.Route.DriverID.String() != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Sin permiso"})
+		return
+	}
+
+	// 2.1 Concurrencia: evita completar sobre una versión vieja (ej: dirección corregida)
+	if !utils.CheckIfMatch(c, wp.Version) {
+		return
+	}
+
+	if wp.IsCompleted {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La parada ya fue entregada"})
+		return
+	}
+
+	// 3. Prueba de entrega: receptor, hora y posición del conductor
+	now := time.Now()
+	proof, ok := parseProofMeta(c, now)
+	if !ok {
+		return
+	}
+	proof.WaypointID = wp.ID
+
+	// 3.1 Geocerca: ¿el conductor está realmente en la parada? (política de la flota)
+	policy, err := fleet.Settings(wp.Route.CreatorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo política de la flota"})
+		return
+	}
+	verdict := geofence.Evaluate(policy, &wp, proof.Latitude, proof.Longitude, proof.AccuracyM)
+	if verdict.Result == geofence.Rejected {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":    "Estás demasiado lejos de la parada para completarla",
+			"code":     "GEOFENCE_REJECTED",
+			"geofence": verdict,
+		})
+		return
+	}
+	proof.GeofenceResult = verdict.Result
+	proof.GeofenceReason = verdict.Reason
+	proof.DistanceM = verdict.DistanceM
+
+	// 3.2 Archivos (fotos + firma): recién ahora que la entrega es válida
+	if !attachProofFiles(c, proof) {
+		return
+	}
+
+	// 4. Actualizar BD
+	before := wp
+	wp.IsCompleted = true
+	wp.CompletedAt = &now
+	wp.FailedAt = nil
+	wp.FailureReason = ""
+	dwell.InferArrival(&wp, proof.Latitude, proof.Longitude, proof.CapturedAt, policy.GeofenceAcceptRadiusM)
+
+	// ProofPhotoURL se mantiene con la primera foto (compatibilidad con clientes viejos)
+	if len(proof.Photos) > 0 {
+		firstPhoto := proof.Photos[0].URL
+		wp.ProofPhotoURL = &firstPhoto
+	}
+
+	attempt := domains.DeliveryAttempt{
+		WaypointID:  wp.ID,
+		RouteID:     wp.RouteID,
+		DriverID:    *wp.Route.DriverID,
+		Outcome:     "delivered",
+		PhotoURL:    wp.ProofPhotoURL,
+		AttemptedAt: now,
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := database.SaveVersioned(tx, &wp); err != nil {
+			return err
+		}
+		if err := tx.Create(&attempt).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(proof).Error; err != nil {
+			return err
+		}
+		if err := webhooks.EnqueueWaypointCompleted(tx, &wp.Route, &wp, proof); err != nil {
+			return err
+		}
+		if err := notify.EnqueueStopResolved(tx, &wp.Route, &wp); err != nil {
+			return err
+		}
+		entry := audit.WaypointEntry("complete", &wp.Route, &before, &wp)
+		entry.Extra = map[string]audit.Change{
+			"proof_id": {From: nil, To: proof.ID},
+			"geofence": {From: nil, To: verdict},
+		}
+		return audit.Record(tx, audit.FromContext(c), entry)
+	})
+	if err != nil {
+		// La entrega no se guardó: sus fotos y firma quedarían huérfanas en el bucket
+		discardProofFiles(proof)
+		if errors.Is(err, database.ErrVersionConflict) {
+			respondWaypointConflict(c, wp.ID)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error guardando cambios"})
+		return
+	}
+
+	realtime.Publish(realtime.RouteEvent(realtime.WaypointCompleted, &wp.Route, gin.H{
+		"waypoint_id":  wp.ID,
+		"completed_at": wp.CompletedAt,
+		"geofence":     verdict.Result,
+	}))
+
+	// Para responder al front, firmamos las URLs recién creadas
+	svc := storage.NewService()
+	var signedURL string
+	if wp.ProofPhotoURL != nil {
+		signedURL, _ = svc.GetSignedURL(*wp.ProofPhotoURL)
+	}
+	SignProof(svc, proof)
+
+	c.Header("ETag", utils.ETag(wp.Version))
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "Entrega completada",
+		"completed_at":     wp.CompletedAt,
+		"proof_signed_url": signedURL,
+		"proof":            proof,
+		"geofence":         verdict,
+	})
+}
@@ -0,0 +1,128 @@
+package waypoints
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/audit"
+	"github.com/tu-usuario/route-manager/api/services/notify"
+	"github.com/tu-usuario/route-manager/api/services/realtime"
+	"github.com/tu-usuario/route-manager/api/services/webhooks"
+	"github.com/tu-usuario/route-manager/api/utils"
+)
+
+// FailWaypointInput acepta JSON o multipart (para adjuntar foto en "photo")
+type FailWaypointInput struct {
+	ReasonCode string `form:"reason_code" json:"reason_code" binding:"required,oneof=customer_absent address_not_found refused damaged other"`
+	Notes      string `form:"notes" json:"notes"`
+}
+
+// MarkWaypointFailed registra un intento de entrega fallido (Conductor asignado).
+// La parada queda pendiente y aparece en el listado de paradas fallidas para reprogramarla.
+func MarkWaypointFailed(c *gin.Context) {
+	waypointID := c.Param("id")
+	userID, _ := c.Get("userID")
+
+	var input FailWaypointInput
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.ReasonCode == domains.ReasonOther && input.Notes == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Con motivo 'other' las notas son obligatorias"})
+		return
+	}
+
+	// 1. Buscar Waypoint
+	var wp domains.Waypoint
+	if err := database.DB.Preload("Route").First(&wp, "id = ?", waypointID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Punto no encontrado"})
+		return
+	}
+
+	// 2. Seguridad: solo el conductor asignado
+	if wp.Route.DriverID == nil || wp.Route.DriverID.String() != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Sin permiso"})
+		return
+	}
+
+	if !utils.CheckIfMatch(c, wp.Version) {
+		return
+	}
+
+	if wp.IsCompleted {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La parada ya fue entregada"})
+		return
+	}
+
+	// 3. Foto opcional (ej: fachada cerrada)
+	photoPath, ok := uploadFormPhoto(c, "photo")
+	if !ok {
+		return
+	}
+
+	// 4. Guardar intento + estado de la parada
+	before := wp
+	now := time.Now()
+	wp.FailedAt = &now
+	wp.FailureReason = input.ReasonCode
+
+	attempt := domains.DeliveryAttempt{
+		WaypointID:  wp.ID,
+		RouteID:     wp.RouteID,
+		DriverID:    *wp.Route.DriverID,
+		Outcome:     "failed",
+		ReasonCode:  input.ReasonCode,
+		Notes:       input.Notes,
+		AttemptedAt: now,
+	}
+	if photoPath != "" {
+		attempt.PhotoURL = &photoPath
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := database.SaveVersioned(tx, &wp); err != nil {
+			return err
+		}
+		if err := tx.Create(&attempt).Error; err != nil {
+			return err
+		}
+		if err := webhooks.EnqueueWaypointFailed(tx, &wp.Route, &wp, &attempt); err != nil {
+			return err
+		}
+		if err := notify.EnqueueStopResolved(tx, &wp.Route, &wp); err != nil {
+			return err
+		}
+		entry := audit.WaypointEntry("fail", &wp.Route, &before, &wp)
+		entry.Extra = map[string]audit.Change{"attempt_id": {From: nil, To: attempt.ID}}
+		return audit.Record(tx, audit.FromContext(c), entry)
+	})
+	if err != nil {
+		// El intento no se guardó: la foto quedaría huérfana en el bucket
+		discardPhoto(photoPath)
+		if errors.Is(err, database.ErrVersionConflict) {
+			respondWaypointConflict(c, wp.ID)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error guardando intento"})
+		return
+	}
+
+	realtime.Publish(realtime.RouteEvent(realtime.WaypointFailed, &wp.Route, gin.H{
+		"waypoint_id": wp.ID,
+		"reason_code": attempt.ReasonCode,
+		"failed_at":   wp.FailedAt,
+	}))
+
+	c.Header("ETag", utils.ETag(wp.Version))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Intento fallido registrado",
+		"attempt": attempt,
+	})
+}
@@ -0,0 +1,65 @@
+package waypoints
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/storage"
+	"github.com/tu-usuario/route-manager/api/utils"
+	"gorm.io/gorm"
+)
+
+// GetWaypoint devuelve una parada con su ETag (para luego editarla con If-Match)
+func GetWaypoint(c *gin.Context) {
+	waypointID := c.Param("id")
+	userID, _ := c.Get("userID")
+
+	var wp domains.Waypoint
+	if err := database.DB.Preload("Route").Preload("Attempts", func(db *gorm.DB) *gorm.DB {
+		return db.Order("attempted_at ASC")
+	}).Preload("Proof", domains.ActiveProof).Preload("Proof.Photos", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	}).First(&wp, "id = ?", waypointID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Punto no encontrado"})
+		return
+	}
+
+	var user domains.User
+	if err := database.DB.Select("id, role").First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario inválido"})
+		return
+	}
+
+	if !canAccessWaypoint(&user, &wp) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Sin permiso"})
+		return
+	}
+
+	SignProof(storage.NewService(), wp.Proof)
+
+	c.Header("ETag", utils.ETag(wp.Version))
+	c.JSON(http.StatusOK, wp)
+}
+
+// canAccessWaypoint aplica las mismas reglas que GetRouteByID:
+// creador de la ruta, conductor asignado o super_admin. wp debe traer su Route precargada.
+func canAccessWaypoint(user *domains.User, wp *domains.Waypoint) bool {
+	switch user.Role {
+	case "super_admin":
+		return true
+	case "admin":
+		return wp.Route.CreatorID == user.ID
+	default:
+		return wp.Route.DriverID != nil && *wp.Route.DriverID == user.ID
+	}
+}
+
+// respondWaypointConflict responde 412 con la versión vigente de la parada
+func respondWaypointConflict(c *gin.Context, waypointID uuid.UUID) {
+	var fresh domains.Waypoint
+	database.DB.Select("version").First(&fresh, "id = ?", waypointID)
+	utils.RespondVersionConflict(c, fresh.Version)
+}
@@ -0,0 +1,53 @@
+package waypoints
+
+import (
+	"log"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/tu-usuario/route-manager/api/services/storage"
+)
+
+// uploadFormPhoto sube al bucket el archivo del campo multipart indicado (si vino).
+// Devuelve el path guardado ("" si no se envió archivo). Si falla, ya respondió al cliente.
+func uploadFormPhoto(c *gin.Context, field string) (string, bool) {
+	fileHeader, err := c.FormFile(field)
+	if err != nil {
+		return "", true // Foto opcional
+	}
+	return uploadFileHeader(c, fileHeader)
+}
+
+// uploadFileHeader sube un archivo multipart y devuelve su path. Si falla, ya respondió al cliente.
+func uploadFileHeader(c *gin.Context, fileHeader *multipart.FileHeader) (string, bool) {
+	// A. Abrir archivo
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error abriendo archivo"})
+		return "", false
+	}
+	defer file.Close()
+
+	// B. Subir a Supabase
+	storageSvc := storage.NewService()
+	contentType := fileHeader.Header.Get("Content-Type")
+	path, err := storageSvc.UploadFile(file, fileHeader.Filename, contentType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error subiendo foto: " + err.Error()})
+		return "", false
+	}
+
+	return path, true
+}
+
+// discardPhoto borra del bucket una foto subida para algo que no se guardó ("" no hace nada)
+func discardPhoto(path string) {
+	if path == "" {
+		return
+	}
+	if err := storage.NewService().DeleteFiles(path); err != nil {
+		log.Printf("Error borrando foto descartada %s: %v", path, err)
+	}
+}
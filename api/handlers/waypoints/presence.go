@@ -0,0 +1,102 @@
+package waypoints
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/audit"
+	"github.com/tu-usuario/route-manager/api/services/dwell"
+	"github.com/tu-usuario/route-manager/api/utils"
+)
+
+// PresenceInput: "at" es opcional (si el dispositivo registró el evento sin conexión)
+type PresenceInput struct {
+	At *time.Time `json:"at"`
+}
+
+// ArriveAtWaypoint registra que el conductor llegó a la parada
+func ArriveAtWaypoint(c *gin.Context) {
+	recordPresence(c, "arrive", dwell.Arrive)
+}
+
+// DepartFromWaypoint registra que el conductor se fue de la parada (calcula el tiempo de servicio)
+func DepartFromWaypoint(c *gin.Context) {
+	recordPresence(c, "depart", dwell.Depart)
+}
+
+func recordPresence(c *gin.Context, action string, apply func(*domains.Waypoint, time.Time, string) error) {
+	waypointID := c.Param("id")
+	userID, _ := c.Get("userID")
+
+	var input PresenceInput
+	if err := c.ShouldBindJSON(&input); err != nil && err.Error() != "EOF" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	at := now
+	if input.At != nil {
+		if input.At.After(now.Add(5 * time.Minute)) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "La hora del evento no puede estar en el futuro"})
+			return
+		}
+		at = *input.At
+	}
+
+	// 1. Buscar Waypoint
+	var wp domains.Waypoint
+	if err := database.DB.Preload("Route").First(&wp, "id = ?", waypointID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Punto no encontrado"})
+		return
+	}
+
+	// 2. Seguridad: solo el conductor asignado, con la ruta en curso
+	if wp.Route.DriverID == nil || wp.Route.DriverID.String() != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Sin permiso"})
+		return
+	}
+	if wp.Route.Status != "in_progress" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La ruta no está en curso"})
+		return
+	}
+
+	if !utils.CheckIfMatch(c, wp.Version) {
+		return
+	}
+
+	// 3. Aplicar evento
+	before := wp
+	if err := apply(&wp, at, dwell.SourceManual); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := database.SaveVersioned(tx, &wp); err != nil {
+			return err
+		}
+		return audit.Record(tx, audit.FromContext(c), audit.WaypointEntry(action, &wp.Route, &before, &wp))
+	})
+	if err != nil {
+		if errors.Is(err, database.ErrVersionConflict) {
+			respondWaypointConflict(c, wp.ID)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error guardando evento"})
+		return
+	}
+
+	c.Header("ETag", utils.ETag(wp.Version))
+	c.JSON(http.StatusOK, gin.H{
+		"arrived_at":    wp.ArrivedAt,
+		"departed_at":   wp.DepartedAt,
+		"dwell_seconds": wp.DwellSeconds,
+	})
+}
@@ -0,0 +1,206 @@
+package waypoints
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/storage"
+)
+
+const (
+	maxProofPhotos   = 10
+	maxSignatureSize = 2 << 20 // 2 MB
+)
+
+// parseProofMeta lee los datos (no archivos) de la prueba de entrega del multipart:
+// recipient_name, latitude, longitude, accuracy (metros), captured_at (RFC3339).
+// Si falla, ya respondió al cliente.
+func parseProofMeta(c *gin.Context, now time.Time) (*domains.ProofOfDelivery, bool) {
+	proof := &domains.ProofOfDelivery{
+		RecipientName: strings.TrimSpace(c.PostForm("recipient_name")),
+		CapturedAt:    now,
+	}
+
+	// 1. Momento de captura (el dispositivo puede haber estado offline)
+	if raw := c.PostForm("captured_at"); raw != "" {
+		capturedAt, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "captured_at debe ser RFC3339"})
+			return nil, false
+		}
+		if capturedAt.After(now.Add(5 * time.Minute)) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "captured_at no puede estar en el futuro"})
+			return nil, false
+		}
+		proof.CapturedAt = capturedAt
+	}
+
+	// 2. GPS (van juntos)
+	rawLat, rawLng := c.PostForm("latitude"), c.PostForm("longitude")
+	if (rawLat == "") != (rawLng == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "latitude y longitude van juntos"})
+		return nil, false
+	}
+	if rawLat != "" {
+		lat, errLat := strconv.ParseFloat(rawLat, 64)
+		lng, errLng := strconv.ParseFloat(rawLng, 64)
+		if errLat != nil || errLng != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Coordenadas inválidas"})
+			return nil, false
+		}
+		proof.Latitude, proof.Longitude = &lat, &lng
+	}
+
+	// 3. Precisión del GPS (metros, la reporta el navegador/dispositivo)
+	if raw := c.PostForm("accuracy"); raw != "" {
+		accuracy, err := strconv.ParseFloat(raw, 64)
+		if err != nil || accuracy < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "accuracy inválida"})
+			return nil, false
+		}
+		proof.AccuracyM = &accuracy
+	}
+
+	return proof, true
+}
+
+// attachProofFiles sube las fotos (photos, o la legacy proof_file) y la firma
+// (signature como PNG o signature_data como data URL del canvas) y las agrega a proof.
+// Si falla, ya respondió al cliente y borró lo que alcanzó a subir.
+func attachProofFiles(c *gin.Context, proof *domains.ProofOfDelivery) (ok bool) {
+	defer func() {
+		if !ok {
+			discardProofFiles(proof)
+		}
+	}()
+
+	// 1. Fotos (la legacy "proof_file" va primero)
+	var photoFiles []*multipart.FileHeader
+	if form, err := c.MultipartForm(); err == nil {
+		photoFiles = append(photoFiles, form.File["proof_file"]...)
+		photoFiles = append(photoFiles, form.File["photos"]...)
+	}
+	if len(photoFiles) > maxProofPhotos {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Máximo %d fotos por entrega", maxProofPhotos)})
+		return false
+	}
+	for i, fh := range photoFiles {
+		path, uploaded := uploadFileHeader(c, fh)
+		if !uploaded {
+			return false
+		}
+		proof.Photos = append(proof.Photos, domains.ProofPhoto{URL: path, Position: i + 1})
+	}
+
+	// 2. Firma
+	signature, valid := readSignature(c)
+	if !valid {
+		return false
+	}
+	if signature != nil {
+		path, err := storage.NewService().UploadFile(bytes.NewReader(signature), "firma.png", "image/png")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error subiendo firma: " + err.Error()})
+			return false
+		}
+		proof.SignatureURL = &path
+	}
+
+	return true
+}
+
+// discardProofFiles borra del bucket los archivos ya subidos de una POD que no se guardó
+// (conflicto de versión, error de BD...). Si el borrado falla, solo queda registrado.
+func discardProofFiles(proof *domains.ProofOfDelivery) {
+	var paths []string
+	for _, photo := range proof.Photos {
+		paths = append(paths, photo.URL)
+	}
+	if proof.SignatureURL != nil {
+		paths = append(paths, *proof.SignatureURL)
+	}
+	if err := storage.NewService().DeleteFiles(paths...); err != nil {
+		log.Printf("Error borrando archivos de POD descartada (parada %s): %v", proof.WaypointID, err)
+	}
+}
+
+// readSignature obtiene el PNG de la firma, como archivo ("signature") o como data URL
+// del canvas ("signature_data"). nil si no se envió. Si falla, ya respondió al cliente.
+func readSignature(c *gin.Context) ([]byte, bool) {
+	var data []byte
+
+	if fileHeader, err := c.FormFile("signature"); err == nil {
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error abriendo firma"})
+			return nil, false
+		}
+		defer file.Close()
+
+		data, err = io.ReadAll(io.LimitReader(file, maxSignatureSize+1))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error leyendo firma"})
+			return nil, false
+		}
+	} else if raw := c.PostForm("signature_data"); raw != "" {
+		// "data:image/png;base64,iVBORw0..."
+		const prefix = "data:image/png;base64,"
+		if !strings.HasPrefix(raw, prefix) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "signature_data debe ser un data URL PNG"})
+			return nil, false
+		}
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(raw, prefix))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "signature_data no es base64 válido"})
+			return nil, false
+		}
+		data = decoded
+	} else {
+		return nil, true // Firma opcional
+	}
+
+	if len(data) > maxSignatureSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La firma supera los 2 MB"})
+		return nil, false
+	}
+	if http.DetectContentType(data) != "image/png" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La firma debe ser una imagen PNG"})
+		return nil, false
+	}
+
+	return data, true
+}
+
+// SignProof reemplaza los paths del bucket por URLs firmadas (solo afecta al JSON de respuesta)
+func SignProof(storageSvc *storage.Service, proof *domains.ProofOfDelivery) {
+	if proof == nil {
+		return
+	}
+
+	if proof.SignatureURL != nil && *proof.SignatureURL != "" {
+		if signedURL, err := storageSvc.GetSignedURL(*proof.SignatureURL); err == nil {
+			proof.SignatureURL = &signedURL
+		} else {
+			fmt.Printf("Error firmando firma de POD %s: %v\n", proof.ID, err)
+		}
+	}
+
+	for i := range proof.Photos {
+		if signedURL, err := storageSvc.GetSignedURL(proof.Photos[i].URL); err == nil {
+			proof.Photos[i].URL = signedURL
+		} else {
+			fmt.Printf("Error firmando foto de POD %s: %v\n", proof.ID, err)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package waypoints
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/audit"
+	"github.com/tu-usuario/route-manager/api/services/dwell"
+	"github.com/tu-usuario/route-manager/api/services/fleet"
+	"github.com/tu-usuario/route-manager/api/services/realtime"
+	"github.com/tu-usuario/route-manager/api/services/webhooks"
+	"github.com/tu-usuario/route-manager/api/utils"
+)
+
+// UndoCompletion permite al conductor deshacer una entrega marcada por error,
+// solo dentro de la ventana de gracia de su flota
+func UndoCompletion(c *gin.Context) {
+	waypointID := c.Param("id")
+	userID, _ := c.Get("userID")
+
+	var wp domains.Waypoint
+	if err := database.DB.Preload("Route").First(&wp, "id = ?", waypointID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Punto no encontrado"})
+		return
+	}
+
+	if wp.Route.DriverID == nil || wp.Route.DriverID.String() != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Sin permiso"})
+		return
+	}
+
+	if !utils.CheckIfMatch(c, wp.Version) {
+		return
+	}
+
+	if !wp.IsCompleted || wp.CompletedAt == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La parada no está completada"})
+		return
+	}
+	if wp.Route.Status == "completed" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La ruta ya fue finalizada: pide a tu administrador que reabra la parada"})
+		return
+	}
+
+	policy, err := fleet.Settings(wp.Route.CreatorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo política de la flota"})
+		return
+	}
+	window := time.Duration(policy.CompletionUndoWindowMin) * time.Minute
+	if time.Since(*wp.CompletedAt) > window {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": fmt.Sprintf("Pasaron más de %d minutos: solo un administrador puede reabrir la parada", policy.CompletionUndoWindowMin),
+			"code":  "UNDO_WINDOW_EXPIRED",
+		})
+		return
+	}
+
+	if !reopenWaypoint(c, &wp, "undo", "Deshecho por el conductor", false) {
+		return
+	}
+
+	c.Header("ETag", utils.ETag(wp.Version))
+	c.JSON(http.StatusOK, gin.H{"message": "Entrega deshecha", "waypoint": wp})
+}
+
+type ReopenWaypointInput struct {
+	Reason string `json:"reason" binding:"required,min=5"`
+}
+
+// ReopenWaypoint (Admin) vuelve a dejar pendiente una parada completada. El motivo es obligatorio.
+// Si la ruta ya estaba finalizada, vuelve a "in_progress".
+func ReopenWaypoint(c *gin.Context) {
+	waypointID := c.Param("id")
+	userID, _ := c.Get("userID")
+
+	var input ReopenWaypointInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Debes indicar el motivo (reason)"})
+		return
+	}
+
+	wp, _, ok := loadWaypointForUser(c, waypointID, userID)
+	if !ok {
+		return
+	}
+
+	if !utils.CheckIfMatch(c, wp.Version) {
+		return
+	}
+
+	if !wp.IsCompleted {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La parada no está completada"})
+		return
+	}
+
+	if !reopenWaypoint(c, wp, "reopen", input.Reason, true) {
+		return
+	}
+
+	c.Header("ETag", utils.ETag(wp.Version))
+	c.JSON(http.StatusOK, gin.H{"message": "Parada reabierta", "waypoint": wp})
+}
+
+type CorrectCompletionInput struct {
+	Reason        string     `json:"reason" binding:"required,min=5"`
+	CompletedAt   *time.Time `json:"completed_at"`
+	RecipientName *string    `json:"recipient_name"`
+}
+
+// CorrectCompletion (Admin) corrige los datos de una entrega sin reabrirla. El motivo es obligatorio.
+func CorrectCompletion(c *gin.Context) {
+	waypointID := c.Param("id")
+	userID, _ := c.Get("userID")
+
+	var input CorrectCompletionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Debes indicar el motivo (reason)"})
+		return
+	}
+	if input.CompletedAt == nil && input.RecipientName == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nada que corregir"})
+		return
+	}
+	if input.CompletedAt != nil && input.CompletedAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "completed_at no puede estar en el futuro"})
+		return
+	}
+
+	wp, _, ok := loadWaypointForUser(c, waypointID, userID)
+	if !ok {
+		return
+	}
+
+	if !utils.CheckIfMatch(c, wp.Version) {
+		return
+	}
+
+	if !wp.IsCompleted {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La parada no está completada"})
+		return
+	}
+
+	var proof domains.ProofOfDelivery
+	hasProof := database.DB.Where("waypoint_id = ? AND "+domains.ActiveProof, wp.ID).First(&proof).Error == nil
+	if input.RecipientName != nil && !hasProof {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La parada no tiene prueba de entrega"})
+		return
+	}
+
+	before := *wp
+	proofBefore := proof
+	if input.CompletedAt != nil {
+		wp.CompletedAt = input.CompletedAt
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := database.SaveVersioned(tx, wp); err != nil {
+			return err
+		}
+
+		changes := map[string]audit.Change{"reason": {From: nil, To: input.Reason}}
+		if input.RecipientName != nil {
+			proof.RecipientName = *input.RecipientName
+			if err := tx.Model(&proof).Update("recipient_name", proof.RecipientName).Error; err != nil {
+				return err
+			}
+			changes["recipient_name"] = audit.Change{From: proofBefore.RecipientName, To: proof.RecipientName}
+		}
+
+		entry := audit.WaypointEntry("correct_completion", &wp.Route, &before, wp)
+		entry.Extra = changes
+		return audit.Record(tx, audit.FromContext(c), entry)
+	})
+	if err != nil {
+		if errors.Is(err, database.ErrVersionConflict) {
+			respondWaypointConflict(c, wp.ID)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error corrigiendo entrega"})
+		return
+	}
+
+	c.Header("ETag", utils.ETag(wp.Version))
+	c.JSON(http.StatusOK, gin.H{"message": "Entrega corregida", "waypoint": wp})
+}
+
+// reopenWaypoint deja la parada pendiente otra vez: la POD vigente pasa a "reemplazada"
+// (se conservan sus archivos) y el intento exitoso queda como "reverted".
+// reopenRoute: si la ruta estaba finalizada vuelve a "in_progress". Si falla, ya respondió al cliente.
+func reopenWaypoint(c *gin.Context, wp *domains.Waypoint, action, reason string, reopenRoute bool) bool {
+	before := *wp
+	now := time.Now()
+	routeReopened := false
+
+	wp.IsCompleted = false
+	wp.CompletedAt = nil
+	wp.ProofPhotoURL = nil
+	dwell.Reset(wp)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := database.SaveVersioned(tx, wp); err != nil {
+			return err
+		}
+
+		var proof domains.ProofOfDelivery
+		hasProof := tx.Where("waypoint_id = ? AND "+domains.ActiveProof, wp.ID).First(&proof).Error == nil
+		if hasProof {
+			if err := tx.Model(&proof).Updates(map[string]interface{}{
+				"superseded_at":     now,
+				"superseded_reason": reason,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		var attempt domains.DeliveryAttempt
+		if tx.Where("waypoint_id = ? AND outcome = ?", wp.ID, "delivered").Order("attempted_at DESC").First(&attempt).Error == nil {
+			if err := tx.Model(&attempt).Update("outcome", "reverted").Error; err != nil {
+				return err
+			}
+		}
+
+		actor := audit.FromContext(c)
+		entry := audit.WaypointEntry(action, &wp.Route, &before, wp)
+		entry.Extra = map[string]audit.Change{"reason": {From: nil, To: reason}}
+		if hasProof {
+			entry.Extra["superseded_proof_id"] = audit.Change{From: proof.ID, To: nil}
+		}
+		if err := audit.Record(tx, actor, entry); err != nil {
+			return err
+		}
+
+		if !reopenRoute {
+			return nil
+		}
+
+		// La ruta precargada puede estar vieja (el If-Match del cliente es de la parada):
+		// se relee bloqueada para no responder un conflicto que el cliente no puede resolver
+		var route domains.Route
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&route, "id = ?", wp.RouteID).Error; err != nil {
+			return err
+		}
+		wp.Route = route
+		if route.Status == "completed" {
+			routeBefore := route
+			wp.Route.Status = "in_progress"
+			if err := database.SaveVersioned(tx, &wp.Route); err != nil {
+				return err
+			}
+			routeReopened = true
+			if err := webhooks.EnqueueRouteStatusChanged(tx, &wp.Route, routeBefore.Status); err != nil {
+				return err
+			}
+			routeEntry := audit.RouteEntry("reopen", &routeBefore, &wp.Route)
+			routeEntry.Extra = map[string]audit.Change{
+				"reason":            {From: nil, To: reason},
+				"reopened_waypoint": {From: nil, To: wp.ID},
+			}
+			return audit.Record(tx, actor, routeEntry)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, database.ErrVersionConflict) {
+			respondWaypointConflict(c, wp.ID)
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error reabriendo parada"})
+		return false
+	}
+
+	realtime.Publish(realtime.RouteEvent(realtime.WaypointReopened, &wp.Route, gin.H{
+		"waypoint_id": wp.ID,
+		"reason":      reason,
+	}))
+	if routeReopened {
+		realtime.Publish(realtime.RouteEvent(realtime.RouteStatus, &wp.Route, gin.H{"status": wp.Route.Status}))
+	}
+
+	return true
+}
@@ -0,0 +1,125 @@
+package waypoints
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/utils"
+)
+
+const (
+	defaultShareHours = 72
+	maxShareHours     = 24 * 30
+)
+
+type ShareWaypointInput struct {
+	ExpiresInHours int `json:"expires_in_hours"` // Opcional (defecto 72h, máx. 30 días)
+}
+
+// ShareWaypoint genera un link público de seguimiento para el cliente de la parada
+func ShareWaypoint(c *gin.Context) {
+	waypointID := c.Param("id")
+	userID, _ := c.Get("userID")
+
+	var input ShareWaypointInput
+	if err := c.ShouldBindJSON(&input); err != nil && err.Error() != "EOF" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hours := input.ExpiresInHours
+	if hours == 0 {
+		hours = defaultShareHours
+	}
+	if hours < 1 || hours > maxShareHours {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("expires_in_hours debe estar entre 1 y %d", maxShareHours)})
+		return
+	}
+
+	wp, user, ok := loadWaypointForUser(c, waypointID, userID)
+	if !ok {
+		return
+	}
+
+	link := domains.TrackingLink{
+		WaypointID: wp.ID,
+		Token:      utils.GenerateSecureToken(24),
+		CreatedBy:  user.ID,
+		ExpiresAt:  time.Now().Add(time.Duration(hours) * time.Hour),
+	}
+	if err := database.DB.Create(&link).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generando link"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"token":        link.Token,
+		"tracking_url": trackingURL(c, link.Token),
+		"expires_at":   link.ExpiresAt,
+	})
+}
+
+// RevokeWaypointShares invalida todos los links vigentes de la parada
+func RevokeWaypointShares(c *gin.Context) {
+	waypointID := c.Param("id")
+	userID, _ := c.Get("userID")
+
+	wp, _, ok := loadWaypointForUser(c, waypointID, userID)
+	if !ok {
+		return
+	}
+
+	result := database.DB.Model(&domains.TrackingLink{}).
+		Where("waypoint_id = ? AND revoked_at IS NULL AND expires_at > ?", wp.ID, time.Now()).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error revocando links"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Links revocados", "revoked": result.RowsAffected})
+}
+
+// loadWaypointForUser busca la parada y valida el acceso. Si falla, ya respondió al cliente.
+func loadWaypointForUser(c *gin.Context, waypointID string, userID interface{}) (*domains.Waypoint, *domains.User, bool) {
+	var wp domains.Waypoint
+	if err := database.DB.Preload("Route").First(&wp, "id = ?", waypointID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Punto no encontrado"})
+		return nil, nil, false
+	}
+
+	var user domains.User
+	if err := database.DB.Select("id, role").First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario inválido"})
+		return nil, nil, false
+	}
+
+	if !canAccessWaypoint(&user, &wp) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Sin permiso"})
+		return nil, nil, false
+	}
+
+	return &wp, &user, true
+}
+
+// trackingURL apunta a la página del frontend si FRONTEND_URL está configurado; si no, a la API
+func trackingURL(c *gin.Context, token string) string {
+	if frontend := strings.TrimRight(os.Getenv("FRONTEND_URL"), "/"); frontend != "" {
+		return fmt.Sprintf("%s/track/%s", frontend, token)
+	}
+
+	scheme := "https"
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	} else if c.Request.TLS == nil {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/api/v1/track/%s", scheme, c.Request.Host, token)
+}
@@ -0,0 +1,137 @@
+package waypoints
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/storage"
+)
+
+// Si la ruta no tiene duración estimada, asumimos este tiempo por parada para el ETA
+const defaultMinutesPerStop = 10
+
+// TrackingView es lo ÚNICO que ve el cliente final: nada de otras paradas ni de otros clientes
+type TrackingView struct {
+	Status        string     `json:"status"` // scheduled, on_the_way, next, delivered, attempt_failed, cancelled
+	Address       string     `json:"address"`
+	StopsBefore   int        `json:"stops_before"`
+	ETA           *time.Time `json:"eta,omitempty"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	ProofPhotoURL string     `json:"proof_photo_url,omitempty"` // Primera foto (compatibilidad)
+	ProofPhotos   []string   `json:"proof_photos,omitempty"`
+	SignatureURL  string     `json:"signature_url,omitempty"`
+	RecipientName string     `json:"recipient_name,omitempty"`
+	LinkExpiresAt time.Time  `json:"link_expires_at"`
+}
+
+// TrackDelivery es el endpoint PÚBLICO del link de seguimiento (autoriza el token de la URL)
+func TrackDelivery(c *gin.Context) {
+	token := c.Param("token")
+	now := time.Now()
+
+	// 1. Validar link
+	var link domains.TrackingLink
+	if err := database.DB.First(&link, "token = ?", token).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Link de seguimiento no encontrado"})
+		return
+	}
+	if !link.IsActive(now) {
+		c.JSON(http.StatusGone, gin.H{"error": "El link de seguimiento expiró"})
+		return
+	}
+
+	// 2. Parada y ruta (si se borraron, el link deja de servir)
+	var wp domains.Waypoint
+	if err := database.DB.Preload("Route").Preload("Proof", domains.ActiveProof).Preload("Proof.Photos", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	}).First(&wp, "id = ?", link.WaypointID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Link de seguimiento no encontrado"})
+		return
+	}
+
+	view := TrackingView{
+		Address:       wp.Address,
+		LinkExpiresAt: link.ExpiresAt,
+	}
+
+	// 3. Entregado: fecha + fotos y firma de la POD (o la foto suelta de entregas anteriores a la POD)
+	if wp.IsCompleted {
+		view.Status = "delivered"
+		view.DeliveredAt = wp.CompletedAt
+		storageSvc := storage.NewService()
+		if wp.Proof != nil {
+			SignProof(storageSvc, wp.Proof)
+			view.RecipientName = wp.Proof.RecipientName
+			for _, photo := range wp.Proof.Photos {
+				view.ProofPhotos = append(view.ProofPhotos, photo.URL)
+			}
+			if wp.Proof.SignatureURL != nil {
+				view.SignatureURL = *wp.Proof.SignatureURL
+			}
+		}
+		if len(view.ProofPhotos) > 0 {
+			view.ProofPhotoURL = view.ProofPhotos[0]
+		} else if wp.ProofPhotoURL != nil && *wp.ProofPhotoURL != "" {
+			view.ProofPhotoURL, _ = storageSvc.GetSignedURL(*wp.ProofPhotoURL)
+		}
+		c.JSON(http.StatusOK, view)
+		return
+	}
+
+	if wp.Route.Status == "cancelled" {
+		view.Status = "cancelled"
+		c.JSON(http.StatusOK, view)
+		return
+	}
+
+	// Intento fallido aún sin reprogramar: no hay ETA que prometer
+	if wp.FailedAt != nil {
+		view.Status = "attempt_failed"
+		c.JSON(http.StatusOK, view)
+		return
+	}
+
+	// 4. Posición en la cola: solo contamos, nunca exponemos las otras paradas
+	// (las fallidas no cuentan: el conductor ya no va a pasar por ellas, igual que en los avisos)
+	var stopsBefore, totalStops int64
+	err := database.DB.Model(&domains.Waypoint{}).
+		Where("route_id = ? AND is_completed = ? AND failed_at IS NULL AND sequence_order < ?", wp.RouteID, false, wp.SequenceOrder).
+		Count(&stopsBefore).Error
+	if err == nil {
+		err = database.DB.Model(&domains.Waypoint{}).Where("route_id = ?", wp.RouteID).Count(&totalStops).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error calculando el seguimiento"})
+		return
+	}
+	view.StopsBefore = int(stopsBefore)
+
+	// 5. ETA aproximado: minutos promedio por parada según la estimación de la ruta
+	perStop := time.Duration(defaultMinutesPerStop) * time.Minute
+	if wp.Route.EstimatedDurationMin > 0 && totalStops > 0 {
+		perStop = time.Duration(wp.Route.EstimatedDurationMin) * time.Minute / time.Duration(totalStops)
+	}
+	ahead := time.Duration(stopsBefore+1) * perStop
+
+	if wp.Route.Status == "in_progress" {
+		view.Status = "on_the_way"
+		if stopsBefore == 0 {
+			view.Status = "next"
+		}
+		eta := now.Add(ahead)
+		view.ETA = &eta
+	} else {
+		view.Status = "scheduled"
+		if wp.Route.ScheduledDate != nil {
+			eta := wp.Route.ScheduledDate.Add(ahead)
+			view.ETA = &eta
+		}
+	}
+
+	c.JSON(http.StatusOK, view)
+}
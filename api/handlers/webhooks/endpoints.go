@@ -0,0 +1,259 @@
+package webhooks
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/fleet"
+	webhooksSvc "github.com/tu-usuario/route-manager/api/services/webhooks"
+	"github.com/tu-usuario/route-manager/api/utils"
+)
+
+type EndpointInput struct {
+	URL         *string  `json:"url"`
+	Description *string  `json:"description"`
+	Events      []string `json:"events"`
+	IsActive    *bool    `json:"is_active"`
+}
+
+// CreateEndpoint registra un webhook para la flota. El secreto se devuelve solo en esta respuesta.
+func CreateEndpoint(c *gin.Context) {
+	adminID, ok := fleet.Resolve(c)
+	if !ok {
+		return
+	}
+
+	var input EndpointInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.URL == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "url es obligatoria"})
+		return
+	}
+
+	endpoint := domains.WebhookEndpoint{
+		AdminID:  adminID,
+		Secret:   newSecret(),
+		IsActive: true,
+	}
+	if !applyEndpointInput(c, &endpoint, &input) {
+		return
+	}
+
+	if err := database.DB.Create(&endpoint).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creando webhook"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"webhook": endpoint, "secret": endpoint.Secret})
+}
+
+// ListEndpoints lista los webhooks de la flota
+func ListEndpoints(c *gin.Context) {
+	adminID, ok := fleet.Resolve(c)
+	if !ok {
+		return
+	}
+
+	var endpoints []domains.WebhookEndpoint
+	if err := database.DB.Where("admin_id = ?", adminID).Order("created_at ASC").Find(&endpoints).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error listando webhooks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhooks": endpoints, "available_events": webhooksSvc.EventTypes})
+}
+
+// UpdateEndpoint edita url, descripción, eventos o activa/pausa el webhook
+func UpdateEndpoint(c *gin.Context) {
+	endpoint, ok := loadEndpoint(c)
+	if !ok {
+		return
+	}
+
+	var input EndpointInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !applyEndpointInput(c, endpoint, &input) {
+		return
+	}
+
+	if err := database.DB.Save(endpoint).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error actualizando webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, endpoint)
+}
+
+// DeleteEndpoint elimina el webhook junto con su historial de envíos
+func DeleteEndpoint(c *gin.Context) {
+	endpoint, ok := loadEndpoint(c)
+	if !ok {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		deliveryIDs := tx.Model(&domains.WebhookDelivery{}).Select("id").Where("endpoint_id = ?", endpoint.ID)
+		if err := tx.Where("delivery_id IN (?)", deliveryIDs).Delete(&domains.WebhookAttempt{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("endpoint_id = ?", endpoint.ID).Delete(&domains.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(endpoint).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error eliminando webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook eliminado"})
+}
+
+// RotateSecret genera un secreto nuevo (el anterior deja de valer de inmediato)
+func RotateSecret(c *gin.Context) {
+	endpoint, ok := loadEndpoint(c)
+	if !ok {
+		return
+	}
+
+	endpoint.Secret = newSecret()
+	if err := database.DB.Model(endpoint).Update("secret", endpoint.Secret).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error rotando secreto"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"secret": endpoint.Secret})
+}
+
+// ListDeliveries es el registro de envíos del webhook con cada intento (?status=pending|delivered|dead)
+func ListDeliveries(c *gin.Context) {
+	endpoint, ok := loadEndpoint(c)
+	if !ok {
+		return
+	}
+
+	query := database.DB.Where("endpoint_id = ?", endpoint.ID).
+		Preload("Attempts", func(db *gorm.DB) *gorm.DB {
+			return db.Order("number ASC")
+		}).
+		Order("created_at DESC").Limit(100)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var deliveries []domains.WebhookDelivery
+	if err := query.Find(&deliveries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error listando envíos"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+// RetryDelivery vuelve a encolar un envío agotado (dead letter) con los intentos en cero
+func RetryDelivery(c *gin.Context) {
+	adminID, ok := fleet.Resolve(c)
+	if !ok {
+		return
+	}
+
+	var delivery domains.WebhookDelivery
+	if err := database.DB.First(&delivery, "id = ?", c.Param("deliveryId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Envío no encontrado"})
+		return
+	}
+
+	var endpoint domains.WebhookEndpoint
+	if err := database.DB.First(&endpoint, "id = ? AND admin_id = ?", delivery.EndpointID, adminID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Envío no encontrado"})
+		return
+	}
+
+	if delivery.Status != domains.DeliveryDead {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solo se reintentan envíos que agotaron los reintentos"})
+		return
+	}
+
+	if err := database.DB.Model(&delivery).Updates(map[string]interface{}{
+		"status":          domains.DeliveryPending,
+		"attempt_count":   0,
+		"next_attempt_at": time.Now(),
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error reencolando envío"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Envío reencolado"})
+}
+
+// applyEndpointInput valida y aplica los campos enviados. Si falla, ya respondió al cliente.
+func applyEndpointInput(c *gin.Context, endpoint *domains.WebhookEndpoint, input *EndpointInput) bool {
+	if input.URL != nil {
+		if err := webhooksSvc.ValidateURL(*input.URL); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return false
+		}
+		endpoint.URL = *input.URL
+	}
+	if input.Description != nil {
+		endpoint.Description = *input.Description
+	}
+	if input.Events != nil {
+		if len(input.Events) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Debes suscribirte al menos a un evento"})
+			return false
+		}
+		seen := map[string]bool{}
+		events := make([]string, 0, len(input.Events))
+		for _, e := range input.Events {
+			if !webhooksSvc.IsEventType(e) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Evento desconocido: " + e, "available_events": webhooksSvc.EventTypes})
+				return false
+			}
+			if !seen[e] {
+				seen[e] = true
+				events = append(events, e)
+			}
+		}
+		endpoint.Events = events
+	}
+	if input.IsActive != nil {
+		endpoint.IsActive = *input.IsActive
+	}
+
+	if len(endpoint.Events) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Debes suscribirte al menos a un evento"})
+		return false
+	}
+	return true
+}
+
+// loadEndpoint busca el webhook de la URL dentro de la flota del usuario. Si falla, ya respondió al cliente.
+func loadEndpoint(c *gin.Context) (*domains.WebhookEndpoint, bool) {
+	adminID, ok := fleet.Resolve(c)
+	if !ok {
+		return nil, false
+	}
+
+	var endpoint domains.WebhookEndpoint
+	if err := database.DB.First(&endpoint, "id = ? AND admin_id = ?", c.Param("id"), adminID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook no encontrado"})
+		return nil, false
+	}
+	return &endpoint, true
+}
+
+func newSecret() string {
+	return "whsec_" + utils.GenerateSecureToken(24)
+}
@@ -0,0 +1,275 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+
+	// Si la primera petición quedó "processing" más que esto (ej: se cayó la instancia), su resultado
+	// es desconocido: no se vuelve a ejecutar (pudo haberse aplicado), el cliente debe verificar y usar otra clave
+	idempotencyStaleAfter = 2 * time.Minute
+
+	// Intentos para guardar la respuesta: si no se guarda, la clave queda "processing" hasta vencer
+	idempotencySaveAttempts = 3
+
+	// Mismo límite en memoria que Gin por defecto para formularios multipart
+	multipartMemory = 32 << 20
+)
+
+// Idempotency hace seguros los reintentos de POST/PATCH que traen el header Idempotency-Key:
+// la primera respuesta (status + body) se guarda por usuario y clave durante ttl y se repite tal cual.
+// Mientras la primera sigue en curso, los duplicados reciben 409. Los errores 5xx no se guardan
+// (el reintento se vuelve a ejecutar). Va después de AuthMiddleware (necesita userID).
+//
+// El contenido se compara por hash: el body tal cual, salvo en multipart, donde cuentan los campos y el SHA-256
+// de cada archivo, porque al reintentar el cliente suele generar otro boundary.
+func Idempotency(ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		method := c.Request.Method
+		if key == "" || (method != http.MethodPost && method != http.MethodPatch) {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key demasiado larga (máx. 255)"})
+			return
+		}
+
+		userID, err := uuid.Parse(c.GetString("userID"))
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		requestHash, err := hashRequest(c.Request)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el cuerpo de la petición"})
+			return
+		}
+
+		path := c.Request.URL.Path
+		now := time.Now()
+		record := domains.IdempotencyKey{
+			UserID:      userID,
+			Key:         key,
+			Method:      method,
+			Path:        path,
+			RequestHash: requestHash,
+			Status:      domains.IdempotencyProcessing,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(ttl),
+		}
+
+		// 1. Reservar la clave (si ya existe, no se inserta)
+		claimed, err := claimIdempotencyKey(&record)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error verificando Idempotency-Key"})
+			return
+		}
+
+		if !claimed {
+			replayIdempotentResponse(c, userID, key, method, path, requestHash)
+			return
+		}
+
+		// 2. Primera ejecución: capturar la respuesta
+		writer := &capturingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		status := writer.Status()
+		if status >= http.StatusInternalServerError {
+			database.DB.Delete(&domains.IdempotencyKey{}, "user_id = ? AND key = ?", userID, key)
+			return
+		}
+
+		updates := map[string]interface{}{
+			"status":          domains.IdempotencyCompleted,
+			"response_status": status,
+			"response_body":   writer.body.Bytes(),
+			"content_type":    writer.Header().Get("Content-Type"),
+			"etag":            writer.Header().Get("ETag"),
+		}
+		for attempt := 1; ; attempt++ {
+			err := database.DB.Model(&domains.IdempotencyKey{}).
+				Where("user_id = ? AND key = ?", userID, key).
+				Updates(updates).Error
+			if err == nil {
+				break
+			}
+			if attempt == idempotencySaveAttempts {
+				// Queda "processing": los reintentos reciben 409 y nunca se vuelve a ejecutar
+				log.Printf("⚠️ Error guardando respuesta idempotente (%s): %v", key, err)
+				break
+			}
+			time.Sleep(time.Duration(attempt) * 100 * time.Millisecond)
+		}
+	}
+}
+
+// hashRequest calcula el hash del contenido de la petición y deja el body listo para el handler.
+// En multipart parsea el formulario (el handler lo reutiliza) y hashea campos + SHA-256 de cada archivo.
+func hashRequest(r *http.Request) (string, error) {
+	hasher := sha256.New()
+
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if contentType != "multipart/form-data" {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return "", err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		hasher.Write(body)
+		return hex.EncodeToString(hasher.Sum(nil)), nil
+	}
+
+	if err := r.ParseMultipartForm(multipartMemory); err != nil {
+		return "", err
+	}
+	form := r.MultipartForm
+
+	fields := make([]string, 0, len(form.Value))
+	for name := range form.Value {
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	for _, name := range fields {
+		for _, value := range form.Value[name] {
+			fmt.Fprintf(hasher, "field:%q=%q\n", name, value)
+		}
+	}
+
+	files := make([]string, 0, len(form.File))
+	for name := range form.File {
+		files = append(files, name)
+	}
+	sort.Strings(files)
+	for _, name := range files {
+		for _, fileHeader := range form.File[name] {
+			sum, err := hashFile(fileHeader)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(hasher, "file:%q=%s\n", name, sum)
+		}
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+func hashFile(fileHeader *multipart.FileHeader) (string, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// claimIdempotencyKey inserta la clave. Devuelve false si ya existe una vigente.
+// Las vencidas se descartan y se vuelve a intentar; las abandonadas en "processing" no (ver replay).
+func claimIdempotencyKey(record *domains.IdempotencyKey) (bool, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+		if result.Error != nil {
+			return false, result.Error
+		}
+		if result.RowsAffected == 1 {
+			return true, nil
+		}
+
+		// Ya existe: ¿sigue vigente?
+		now := time.Now()
+		deleted := database.DB.
+			Where("user_id = ? AND key = ?", record.UserID, record.Key).
+			Where("expires_at < ?", now).
+			Delete(&domains.IdempotencyKey{})
+		if deleted.Error != nil {
+			return false, deleted.Error
+		}
+		if deleted.RowsAffected == 0 {
+			return false, nil
+		}
+	}
+	return false, nil
+}
+
+// replayIdempotentResponse responde a un reintento con la respuesta guardada
+func replayIdempotentResponse(c *gin.Context, userID uuid.UUID, key, method, path, requestHash string) {
+	var existing domains.IdempotencyKey
+	if err := database.DB.First(&existing, "user_id = ? AND key = ?", userID, key).Error; err != nil {
+		// Se liberó entre medio (la primera terminó con 5xx): que el cliente reintente
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Petición con la misma Idempotency-Key en curso", "code": "IDEMPOTENCY_IN_PROGRESS"})
+		return
+	}
+
+	if existing.Method != method || existing.Path != path {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "La Idempotency-Key ya se usó en otra operación", "code": "IDEMPOTENCY_KEY_REUSED"})
+		return
+	}
+
+	if existing.Status == domains.IdempotencyProcessing && existing.CreatedAt.Before(time.Now().Add(-idempotencyStaleAfter)) {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "No se sabe si la petición original se aplicó: verifica el estado y reintenta con otra Idempotency-Key", "code": "IDEMPOTENCY_OUTCOME_UNKNOWN"})
+		return
+	}
+	if existing.Status == domains.IdempotencyProcessing {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Petición con la misma Idempotency-Key en curso", "code": "IDEMPOTENCY_IN_PROGRESS"})
+		return
+	}
+
+	if requestHash != existing.RequestHash {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "La Idempotency-Key ya se usó con otro contenido", "code": "IDEMPOTENCY_KEY_REUSED"})
+		return
+	}
+
+	c.Header(IdempotencyReplayedHeader, "true")
+	if existing.ETag != "" {
+		c.Header("ETag", existing.ETag)
+	}
+	c.Data(existing.ResponseStatus, existing.ContentType, existing.ResponseBody)
+	c.Abort()
+}
+
+// capturingWriter copia la respuesta mientras se envía al cliente
+type capturingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *capturingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *capturingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,139 @@
+package assignment
+
+import (
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// Pesos del puntaje (penalizaciones). Puntaje final = 100 - suma de penalizaciones.
+const (
+	PenaltyPerRoute  = 15.0 // Por cada ruta que ya tiene ese día
+	PenaltyPerHour   = 5.0  // Por cada hora ya reservada ese día
+	PenaltyPerKm     = 0.5  // Por km desde su ubicación hasta la primera parada
+	PenaltyNoOrigin  = 10.0 // No sabemos dónde está (ni base ni última entrega)
+	MaxWorkdayHours  = 10.0 // Tope de jornada: si lo supera, no es elegible
+	CapacityFitBonus = 5.0  // Premio si el vehículo va "justo" (aprovecha capacidad)
+)
+
+// Origin indica de dónde sacamos la ubicación del conductor
+const (
+	OriginHome         = "home"
+	OriginLastDelivery = "last_delivery"
+	OriginUnknown      = "unknown"
+)
+
+// DriverLoad es la foto del conductor que necesita el puntaje
+type DriverLoad struct {
+	DriverID        uuid.UUID
+	DriverName      string
+	RoutesSameDay   int
+	BookedMinutes   int
+	Latitude        *float64
+	Longitude       *float64
+	Origin          string
+	VehicleCapacity int
+
+	// Disponibilidad (ausencias / horario semanal)
+	Unavailable []string
+	Warnings    []string
+}
+
+// RouteDemand es lo que pide la ruta a asignar
+type RouteDemand struct {
+	Stops         int
+	DurationMin   int
+	FirstStopLat  float64
+	FirstStopLng  float64
+	HasFirstStop  bool
+	HasScheduling bool // Sin fecha no se puede medir la carga del día
+}
+
+// Candidate es el resultado por conductor (ordenados de mejor a peor)
+type Candidate struct {
+	DriverID      uuid.UUID `json:"driver_id"`
+	DriverName    string    `json:"driver_name"`
+	Score         float64   `json:"score"`
+	Eligible      bool      `json:"eligible"`
+	Reasons       []string  `json:"reasons"`
+	RoutesSameDay int       `json:"routes_same_day"`
+	BookedHours   float64   `json:"booked_hours"`
+	DistanceKm    *float64  `json:"distance_km"`
+	Origin        string    `json:"origin"`
+	Capacity      int       `json:"vehicle_capacity"`
+}
+
+// Rank puntúa a cada conductor para la ruta y devuelve la lista ordenada:
+// primero los elegibles, y dentro de cada grupo de mayor a menor puntaje.
+func Rank(demand RouteDemand, loads []DriverLoad, distance func(lat1, lon1, lat2, lon2 float64) float64) []Candidate {
+	candidates := make([]Candidate, 0, len(loads))
+
+	for _, load := range loads {
+		cand := Candidate{
+			DriverID:      load.DriverID,
+			DriverName:    load.DriverName,
+			Eligible:      true,
+			Reasons:       []string{},
+			RoutesSameDay: load.RoutesSameDay,
+			BookedHours:   round1(float64(load.BookedMinutes) / 60),
+			Origin:        load.Origin,
+			Capacity:      load.VehicleCapacity,
+		}
+		penalty := 0.0
+
+		// 1. Carga del día
+		if demand.HasScheduling {
+			penalty += float64(load.RoutesSameDay) * PenaltyPerRoute
+			penalty += cand.BookedHours * PenaltyPerHour
+
+			totalHours := float64(load.BookedMinutes+demand.DurationMin) / 60
+			if totalHours > MaxWorkdayHours {
+				cand.Eligible = false
+				cand.Reasons = append(cand.Reasons, "Supera la jornada máxima del día")
+			}
+		}
+
+		// 2. Distancia a la primera parada
+		if demand.HasFirstStop && load.Latitude != nil && load.Longitude != nil {
+			km := round1(distance(*load.Latitude, *load.Longitude, demand.FirstStopLat, demand.FirstStopLng))
+			cand.DistanceKm = &km
+			penalty += km * PenaltyPerKm
+		} else {
+			penalty += PenaltyNoOrigin
+		}
+
+		// 3. Disponibilidad
+		if len(load.Unavailable) > 0 {
+			cand.Eligible = false
+			cand.Reasons = append(cand.Reasons, load.Unavailable...)
+		}
+		cand.Reasons = append(cand.Reasons, load.Warnings...)
+
+		// 4. Capacidad del vehículo
+		if load.VehicleCapacity > 0 {
+			if demand.Stops > load.VehicleCapacity {
+				cand.Eligible = false
+				cand.Reasons = append(cand.Reasons, "Capacidad del vehículo insuficiente")
+			} else if float64(demand.Stops) >= float64(load.VehicleCapacity)*0.8 {
+				penalty -= CapacityFitBonus
+			}
+		}
+
+		cand.Score = round1(math.Max(0, math.Min(100, 100-penalty)))
+		candidates = append(candidates, cand)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Eligible != candidates[j].Eligible {
+			return candidates[i].Eligible
+		}
+		return candidates[i].Score > candidates[j].Score
+	})
+
+	return candidates
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,147 @@
+package assignment
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/availability"
+	"github.com/tu-usuario/route-manager/api/services/optimization"
+)
+
+// Suggest arma el ranking de conductores activos de la flota dueña de la ruta.
+// La ruta debe venir con sus Waypoints precargados.
+func Suggest(route *domains.Route) ([]Candidate, error) {
+	loads, err := loadFleetDrivers(route)
+	if err != nil {
+		return nil, err
+	}
+
+	return Rank(buildDemand(route), loads, optimization.HaversineDistance), nil
+}
+
+// buildDemand resume la ruta: cantidad de paradas, duración y primera parada
+func buildDemand(route *domains.Route) RouteDemand {
+	demand := RouteDemand{
+		Stops:         len(route.Waypoints),
+		DurationMin:   route.EstimatedDurationMin,
+		HasScheduling: route.ScheduledDate != nil,
+	}
+
+	firstSequence := 0
+	for _, wp := range route.Waypoints {
+		if !demand.HasFirstStop || wp.SequenceOrder < firstSequence {
+			firstSequence = wp.SequenceOrder
+			demand.FirstStopLat = wp.Latitude
+			demand.FirstStopLng = wp.Longitude
+			demand.HasFirstStop = true
+		}
+	}
+
+	return demand
+}
+
+// loadFleetDrivers junta, para cada conductor de la flota, su carga del día y su ubicación
+func loadFleetDrivers(route *domains.Route) ([]DriverLoad, error) {
+	var drivers []domains.User
+	if err := database.DB.
+		Where("manager_id = ? AND role = 'driver' AND status = 'active'", route.CreatorID).
+		Find(&drivers).Error; err != nil {
+		return nil, err
+	}
+	if len(drivers) == 0 {
+		return []DriverLoad{}, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(drivers))
+	for _, d := range drivers {
+		ids = append(ids, d.ID)
+	}
+
+	// 1. Carga del mismo día (una sola query agrupada)
+	type dayLoad struct {
+		DriverID uuid.UUID
+		Routes   int
+		Minutes  int
+	}
+	loadsByDriver := map[uuid.UUID]dayLoad{}
+
+	if route.ScheduledDate != nil {
+		d := *route.ScheduledDate
+		dayStart := time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, d.Location())
+		dayEnd := dayStart.AddDate(0, 0, 1)
+
+		var rows []dayLoad
+		if err := database.DB.Model(&domains.Route{}).
+			Select("driver_id, COUNT(*) AS routes, COALESCE(SUM(estimated_duration_min), 0) AS minutes").
+			Where("driver_id IN ?", ids).
+			Where("id <> ?", route.ID).
+			Where("status <> ?", "cancelled").
+			Where("scheduled_date >= ? AND scheduled_date < ?", dayStart, dayEnd).
+			Group("driver_id").
+			Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			loadsByDriver[row.DriverID] = row
+		}
+	}
+
+	// 2. Última entrega de cada conductor (fallback si no tiene base configurada)
+	type lastStop struct {
+		DriverID  uuid.UUID
+		Latitude  float64
+		Longitude float64
+	}
+	var lastStops []lastStop
+	if err := database.DB.Raw(`
+		SELECT DISTINCT ON (routes.driver_id) routes.driver_id, waypoints.latitude, waypoints.longitude
+		FROM waypoints
+		JOIN routes ON waypoints.route_id = routes.id
+		WHERE routes.driver_id IN ? AND waypoints.is_completed = true
+			AND routes.deleted_at IS NULL AND waypoints.deleted_at IS NULL
+		ORDER BY routes.driver_id, waypoints.completed_at DESC`, ids).
+		Scan(&lastStops).Error; err != nil {
+		return nil, err
+	}
+	lastByDriver := map[uuid.UUID]lastStop{}
+	for _, ls := range lastStops {
+		lastByDriver[ls.DriverID] = ls
+	}
+
+	// 3. Disponibilidad (ausencias y horario semanal)
+	availabilityByDriver, err := availability.CheckMany(ids, route.ScheduledDate, route.EstimatedDurationMin)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4. Armar la foto de cada conductor
+	loads := make([]DriverLoad, 0, len(drivers))
+	for _, d := range drivers {
+		load := DriverLoad{
+			DriverID:        d.ID,
+			DriverName:      d.FullName,
+			RoutesSameDay:   loadsByDriver[d.ID].Routes,
+			BookedMinutes:   loadsByDriver[d.ID].Minutes,
+			Origin:          OriginUnknown,
+			VehicleCapacity: d.VehicleCapacity,
+			Unavailable:     availabilityByDriver[d.ID].Blocking,
+			Warnings:        availabilityByDriver[d.ID].Warnings,
+		}
+
+		if d.HomeLatitude != nil && d.HomeLongitude != nil {
+			load.Latitude, load.Longitude = d.HomeLatitude, d.HomeLongitude
+			load.Origin = OriginHome
+		} else if ls, ok := lastByDriver[d.ID]; ok {
+			lat, lng := ls.Latitude, ls.Longitude
+			load.Latitude, load.Longitude = &lat, &lng
+			load.Origin = OriginLastDelivery
+		}
+
+		loads = append(loads, load)
+	}
+
+	return loads, nil
+}
@@ -0,0 +1,154 @@
+package audit
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/domains"
+)
+
+const (
+	EntityRoute    = "route"
+	EntityWaypoint = "waypoint"
+	EntityUser     = "user"
+
+	EntityFleetSettings = "fleet_settings"
+)
+
+// ignoredFields no aportan al historial (cambian en cada guardado o son relaciones)
+var ignoredFields = map[string]bool{
+	"updated_at": true,
+	"version":    true,
+	"creator":    true,
+	"driver":     true,
+	"waypoints":  true,
+	"drivers":    true,
+	"route":      true,
+	"attempts":   true,
+	"proof":      true,
+}
+
+// Change es el antes/después de un campo
+type Change struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// Actor identifica quién hizo el cambio y desde dónde
+type Actor struct {
+	ID        *uuid.UUID
+	IP        string
+	RequestID string
+}
+
+// Entry describe una mutación. Before/After son snapshots del modelo (nil en create/delete).
+// Extra permite agregar cambios que no son campos del modelo (ej: paradas movidas).
+type Entry struct {
+	EntityType string
+	EntityID   uuid.UUID
+	Action     string
+	RouteID    *uuid.UUID
+	OwnerID    *uuid.UUID
+	Before     interface{}
+	After      interface{}
+	Extra      map[string]Change
+}
+
+// FromContext arma el Actor a partir de lo que dejaron los middlewares (auth + request id)
+func FromContext(c *gin.Context) Actor {
+	actor := Actor{IP: c.ClientIP(), RequestID: c.GetString("requestID")}
+	if raw := c.GetString("userID"); raw != "" {
+		if id, err := uuid.Parse(raw); err == nil {
+			actor.ID = &id
+		}
+	}
+	return actor
+}
+
+// System es el actor de los procesos en segundo plano
+func System() Actor {
+	return Actor{}
+}
+
+// Record guarda la entrada de auditoría usando tx (para que sea atómica con el cambio).
+// Si es un "update" sin diferencias reales, no guarda nada.
+func Record(tx *gorm.DB, actor Actor, entry Entry) error {
+	changes := Diff(entry.Before, entry.After)
+	for field, change := range entry.Extra {
+		changes[field] = change
+	}
+
+	if len(changes) == 0 && entry.Before != nil && entry.After != nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&domains.AuditLog{
+		EntityType: entry.EntityType,
+		EntityID:   entry.EntityID,
+		Action:     entry.Action,
+		RouteID:    entry.RouteID,
+		OwnerID:    entry.OwnerID,
+		ActorID:    actor.ID,
+		IP:         actor.IP,
+		RequestID:  actor.RequestID,
+		Changes:    raw,
+	}).Error
+}
+
+// RecordBestEffort es para flujos que no usan transacción: un fallo de auditoría
+// se loguea pero no revierte la operación de negocio.
+func RecordBestEffort(tx *gorm.DB, actor Actor, entry Entry) {
+	if err := Record(tx, actor, entry); err != nil {
+		log.Printf("⚠️ Error guardando auditoría (%s %s): %v", entry.EntityType, entry.EntityID, err)
+	}
+}
+
+// Diff compara dos snapshots campo a campo (según su JSON) y devuelve solo lo que cambió
+func Diff(before, after interface{}) map[string]Change {
+	b := toMap(before)
+	a := toMap(after)
+	changes := map[string]Change{}
+
+	for key, newVal := range a {
+		if ignoredFields[key] {
+			continue
+		}
+		oldVal, existed := b[key]
+		if !existed || !reflect.DeepEqual(oldVal, newVal) {
+			changes[key] = Change{From: oldVal, To: newVal}
+		}
+	}
+	for key, oldVal := range b {
+		if ignoredFields[key] {
+			continue
+		}
+		if _, still := a[key]; !still {
+			changes[key] = Change{From: oldVal, To: nil}
+		}
+	}
+
+	return changes
+}
+
+func toMap(v interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return result
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return result
+	}
+	_ = json.Unmarshal(raw, &result)
+	return result
+}
@@ -0,0 +1,86 @@
+package audit
+
+import (
+	"github.com/tu-usuario/route-manager/api/domains"
+)
+
+// RouteEntry arma la entrada para una ruta. before/after pueden ser nil (create/delete).
+func RouteEntry(action string, before, after *domains.Route) Entry {
+	ref := after
+	if ref == nil {
+		ref = before
+	}
+	routeID := ref.ID
+	ownerID := ref.CreatorID
+
+	entry := Entry{
+		EntityType: EntityRoute,
+		EntityID:   ref.ID,
+		Action:     action,
+		RouteID:    &routeID,
+		OwnerID:    &ownerID,
+	}
+	// Evitamos guardar interfaces con punteros nil tipados
+	if before != nil {
+		entry.Before = before
+	}
+	if after != nil {
+		entry.After = after
+	}
+	return entry
+}
+
+// WaypointEntry arma la entrada para una parada (route aporta el agrupador y el dueño)
+func WaypointEntry(action string, route *domains.Route, before, after *domains.Waypoint) Entry {
+	ref := after
+	if ref == nil {
+		ref = before
+	}
+	routeID := ref.RouteID
+
+	entry := Entry{
+		EntityType: EntityWaypoint,
+		EntityID:   ref.ID,
+		Action:     action,
+		RouteID:    &routeID,
+	}
+	if route != nil {
+		ownerID := route.CreatorID
+		entry.OwnerID = &ownerID
+	}
+	if before != nil {
+		entry.Before = before
+	}
+	if after != nil {
+		entry.After = after
+	}
+	return entry
+}
+
+// UserEntry arma la entrada para un usuario. El dueño es su jefe (o él mismo si es Admin).
+func UserEntry(action string, before, after *domains.User) Entry {
+	ref := after
+	if ref == nil {
+		ref = before
+	}
+
+	entry := Entry{
+		EntityType: EntityUser,
+		EntityID:   ref.ID,
+		Action:     action,
+	}
+	if ref.ManagerID != nil {
+		ownerID := *ref.ManagerID
+		entry.OwnerID = &ownerID
+	} else if ref.Role == "admin" {
+		ownerID := ref.ID
+		entry.OwnerID = &ownerID
+	}
+	if before != nil {
+		entry.Before = before
+	}
+	if after != nil {
+		entry.After = after
+	}
+	return entry
+}
@@ -0,0 +1,146 @@
+package availability
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+)
+
+var weekdayNames = []string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"}
+
+// Result indica si el conductor puede tomar una ruta en esa fecha.
+// Blocking impide la asignación; Warnings se informan pero no la frenan.
+type Result struct {
+	Available bool     `json:"available"`
+	Blocking  []string `json:"blocking"`
+	Warnings  []string `json:"warnings"`
+}
+
+// Check evalúa la disponibilidad de un conductor para una ruta
+func Check(driverID uuid.UUID, scheduled *time.Time, durationMin int) (Result, error) {
+	results, err := CheckMany([]uuid.UUID{driverID}, scheduled, durationMin)
+	if err != nil {
+		return Result{}, err
+	}
+	return results[driverID], nil
+}
+
+// CheckMany evalúa varios conductores con dos queries en total (para la asignación automática).
+// Sin fecha programada no hay nada que validar: todos quedan disponibles.
+func CheckMany(driverIDs []uuid.UUID, scheduled *time.Time, durationMin int) (map[uuid.UUID]Result, error) {
+	results := make(map[uuid.UUID]Result, len(driverIDs))
+	for _, id := range driverIDs {
+		results[id] = Result{Available: true, Blocking: []string{}, Warnings: []string{}}
+	}
+	if scheduled == nil || len(driverIDs) == 0 {
+		return results, nil
+	}
+
+	start := scheduled.In(time.Local)
+	day := start.Format("2006-01-02")
+
+	// 1. Ausencias que cubren el día (aprobadas bloquean, pendientes avisan)
+	var timeOffs []domains.TimeOff
+	if err := database.DB.
+		Where("driver_id IN ?", driverIDs).
+		Where("status IN ?", []string{"approved", "pending"}).
+		Where("start_date <= ? AND end_date >= ?", day, day).
+		Find(&timeOffs).Error; err != nil {
+		return nil, err
+	}
+
+	for _, t := range timeOffs {
+		r := results[t.DriverID]
+		period := fmt.Sprintf("%s (%s al %s)", t.Type, t.StartDate.Format("2006-01-02"), t.EndDate.Format("2006-01-02"))
+		if t.Status == "approved" {
+			r.Blocking = append(r.Blocking, "Ausencia aprobada: "+period)
+		} else {
+			r.Warnings = append(r.Warnings, "Ausencia pendiente de aprobación: "+period)
+		}
+		results[t.DriverID] = r
+	}
+
+	// 2. Horario semanal
+	var hours []domains.WorkingHours
+	if err := database.DB.Where("driver_id IN ?", driverIDs).Find(&hours).Error; err != nil {
+		return nil, err
+	}
+
+	hoursByDriver := map[uuid.UUID][]domains.WorkingHours{}
+	for _, h := range hours {
+		hoursByDriver[h.DriverID] = append(hoursByDriver[h.DriverID], h)
+	}
+
+	for driverID, slots := range hoursByDriver {
+		r := results[driverID]
+		if msg, blocking := checkWorkingHours(slots, start, durationMin); msg != "" {
+			if blocking {
+				r.Blocking = append(r.Blocking, msg)
+			} else {
+				r.Warnings = append(r.Warnings, msg)
+			}
+		}
+		results[driverID] = r
+	}
+
+	for id, r := range results {
+		r.Available = len(r.Blocking) == 0
+		results[id] = r
+	}
+
+	return results, nil
+}
+
+// checkWorkingHours: no trabajar ese día bloquea; salirse del horario solo avisa.
+// Una ruta programada a las 00:00 se interpreta como "solo fecha" y no valida horas.
+func checkWorkingHours(slots []domains.WorkingHours, start time.Time, durationMin int) (string, bool) {
+	var today []domains.WorkingHours
+	for _, s := range slots {
+		if s.Weekday == int(start.Weekday()) {
+			today = append(today, s)
+		}
+	}
+
+	if len(today) == 0 {
+		return "El conductor no trabaja los " + weekdayNames[start.Weekday()], true
+	}
+
+	startMin := start.Hour()*60 + start.Minute()
+	if startMin == 0 {
+		return "", false
+	}
+	endMin := startMin + durationMin
+
+	for _, s := range today {
+		from, errFrom := ParseClock(s.StartTime)
+		to, errTo := ParseClock(s.EndTime)
+		if errFrom != nil || errTo != nil {
+			continue
+		}
+		if startMin >= from && endMin <= to {
+			return "", false
+		}
+	}
+
+	return fmt.Sprintf("La ruta (%s, %d min) queda fuera del horario laboral del conductor", start.Format("15:04"), durationMin), false
+}
+
+// ParseClock convierte "HH:MM" a minutos desde medianoche
+func ParseClock(value string) (int, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("hora inválida: %s", value)
+	}
+	h, errH := strconv.Atoi(parts[0])
+	m, errM := strconv.Atoi(parts[1])
+	if errH != nil || errM != nil || h < 0 || h > 24 || m < 0 || m > 59 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("hora inválida: %s", value)
+	}
+	return h*60 + m, nil
+}
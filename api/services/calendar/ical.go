@@ -0,0 +1,156 @@
+package calendar
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tu-usuario/route-manager/api/domains"
+)
+
+const (
+	defaultEventMinutes = 60
+	icsTimeFormat       = "20060102T150405Z"
+	icsDateFormat       = "20060102"
+)
+
+// Feed genera calendarios iCalendar (RFC 5545) con las rutas de un conductor
+type Feed struct {
+	frontendURL string
+}
+
+func NewFeed() *Feed {
+	return &Feed{
+		frontendURL: strings.TrimRight(os.Getenv("FRONTEND_URL"), "/"),
+	}
+}
+
+// RouteURL es el link de vuelta a la ruta en el frontend (vacío si no hay FRONTEND_URL)
+func (f *Feed) RouteURL(route *domains.Route) string {
+	if f.frontendURL == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/routes/%s", f.frontendURL, route.ID)
+}
+
+// Build arma el VCALENDAR con un VEVENT por ruta programada.
+// Las rutas deben venir con sus Waypoints precargados (para la primera parada).
+func (f *Feed) Build(calendarName string, routes []domains.Route) string {
+	var b strings.Builder
+	now := time.Now().UTC().Format(icsTimeFormat)
+
+	writeLine(&b, "BEGIN:VCALENDAR")
+	writeLine(&b, "VERSION:2.0")
+	writeLine(&b, "PRODID:-//Route Manager//Driver Feed//ES")
+	writeLine(&b, "CALSCALE:GREGORIAN")
+	writeLine(&b, "METHOD:PUBLISH")
+	writeLine(&b, "X-WR-CALNAME:"+escapeText(calendarName))
+	// Sugerencia a los clientes para refrescar seguido
+	writeLine(&b, "REFRESH-INTERVAL;VALUE=DURATION:PT15M")
+	writeLine(&b, "X-PUBLISHED-TTL:PT15M")
+
+	for i := range routes {
+		route := &routes[i]
+		if route.ScheduledDate == nil {
+			continue
+		}
+
+		writeLine(&b, "BEGIN:VEVENT")
+		writeLine(&b, "UID:route-"+route.ID.String()+"@route-manager")
+		writeLine(&b, "DTSTAMP:"+now)
+		writeLine(&b, "LAST-MODIFIED:"+route.UpdatedAt.UTC().Format(icsTimeFormat))
+		// SEQUENCE cambia con cada edición para que el cliente reemplace el evento
+		writeLine(&b, fmt.Sprintf("SEQUENCE:%d", route.Version))
+
+		start := *route.ScheduledDate
+		if isDateOnly(start) {
+			// Ruta sin hora: evento de día completo
+			writeLine(&b, "DTSTART;VALUE=DATE:"+start.Format(icsDateFormat))
+			writeLine(&b, "DTEND;VALUE=DATE:"+start.AddDate(0, 0, 1).Format(icsDateFormat))
+		} else {
+			minutes := route.EstimatedDurationMin
+			if minutes <= 0 {
+				minutes = defaultEventMinutes
+			}
+			writeLine(&b, "DTSTART:"+start.UTC().Format(icsTimeFormat))
+			writeLine(&b, "DTEND:"+start.Add(time.Duration(minutes)*time.Minute).UTC().Format(icsTimeFormat))
+		}
+
+		writeLine(&b, "SUMMARY:"+escapeText(route.Name))
+
+		description := fmt.Sprintf("%d paradas · %.1f km · estado: %s", len(route.Waypoints), route.TotalDistanceKm, route.Status)
+		if first := firstStop(route.Waypoints); first != nil {
+			writeLine(&b, "LOCATION:"+escapeText(first.Address))
+			writeLine(&b, fmt.Sprintf("GEO:%.6f;%.6f", first.Latitude, first.Longitude))
+			description += "\nPrimera parada: " + first.Address
+		}
+
+		if link := f.RouteURL(route); link != "" {
+			writeLine(&b, "URL:"+link)
+			description += "\n" + link
+		}
+		writeLine(&b, "DESCRIPTION:"+escapeText(description))
+
+		switch route.Status {
+		case "cancelled":
+			writeLine(&b, "STATUS:CANCELLED")
+		case "draft":
+			writeLine(&b, "STATUS:TENTATIVE")
+		default:
+			writeLine(&b, "STATUS:CONFIRMED")
+		}
+
+		writeLine(&b, "END:VEVENT")
+	}
+
+	writeLine(&b, "END:VCALENDAR")
+	return b.String()
+}
+
+func firstStop(waypoints []domains.Waypoint) *domains.Waypoint {
+	if len(waypoints) == 0 {
+		return nil
+	}
+	ordered := make([]domains.Waypoint, len(waypoints))
+	copy(ordered, waypoints)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].SequenceOrder < ordered[j].SequenceOrder
+	})
+	return &ordered[0]
+}
+
+func isDateOnly(t time.Time) bool {
+	return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0
+}
+
+// escapeText aplica el escape de TEXT de RFC 5545
+func escapeText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+// writeLine escribe la línea con CRLF, plegando a 75 octetos como exige el estándar.
+// Las continuaciones empiezan con un espacio, así que llevan como máximo 74 octetos de contenido.
+func writeLine(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		// No partir un carácter UTF-8 por la mitad
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
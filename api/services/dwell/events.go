@@ -0,0 +1,109 @@
+package dwell
+
+import (
+	"errors"
+	"time"
+
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/optimization"
+)
+
+// Origen de un evento de llegada/salida
+const (
+	SourceManual   = "manual"   // El conductor tocó "Llegué" / "Me voy"
+	SourceInferred = "inferred" // Deducido de la posición GPS
+)
+
+// Al inferir, la salida se detecta al alejarse este múltiplo del radio de llegada
+// (histéresis para que el ruido del GPS no genere salidas falsas)
+const departureRadiusFactor = 1.5
+
+var (
+	ErrAlreadyArrived  = errors.New("ya se registró la llegada a esta parada")
+	ErrNotArrived      = errors.New("no se registró la llegada a esta parada")
+	ErrAlreadyDeparted = errors.New("ya se registró la salida de esta parada")
+	ErrBeforeArrival   = errors.New("la salida no puede ser anterior a la llegada")
+)
+
+// Arrive registra la llegada en la parada (no persiste)
+func Arrive(wp *domains.Waypoint, at time.Time, source string) error {
+	if wp.ArrivedAt != nil {
+		return ErrAlreadyArrived
+	}
+	wp.ArrivedAt = &at
+	wp.ArrivalSource = source
+	return nil
+}
+
+// Depart registra la salida y calcula el tiempo de servicio (no persiste)
+func Depart(wp *domains.Waypoint, at time.Time, source string) error {
+	if wp.ArrivedAt == nil {
+		return ErrNotArrived
+	}
+	if wp.DepartedAt != nil {
+		return ErrAlreadyDeparted
+	}
+	if at.Before(*wp.ArrivedAt) {
+		return ErrBeforeArrival
+	}
+
+	seconds := int(at.Sub(*wp.ArrivedAt).Seconds())
+	wp.DepartedAt = &at
+	wp.DepartureSource = source
+	wp.DwellSeconds = &seconds
+	return nil
+}
+
+// Reset borra llegada, salida y tiempo de servicio: la parada se vuelve a visitar
+// (reprogramada o entrega reabierta) y no debe arrastrar la visita anterior (no persiste)
+func Reset(wp *domains.Waypoint) {
+	wp.ArrivedAt = nil
+	wp.DepartedAt = nil
+	wp.ArrivalSource = ""
+	wp.DepartureSource = ""
+	wp.DwellSeconds = nil
+}
+
+// ResetColumns son las columnas que borra Reset, para actualizaciones masivas
+func ResetColumns() map[string]interface{} {
+	return map[string]interface{}{
+		"arrived_at":       nil,
+		"departed_at":      nil,
+		"arrival_source":   "",
+		"departure_source": "",
+		"dwell_seconds":    nil,
+	}
+}
+
+// InferArrival usa la posición con la que se completa la parada: si el conductor no marcó
+// la llegada y está dentro de radiusM, la llegada queda registrada a esa hora.
+// Devuelve true si la registró.
+func InferArrival(wp *domains.Waypoint, lat, lng *float64, at time.Time, radiusM int) bool {
+	if wp.ArrivedAt != nil || lat == nil || lng == nil {
+		return false
+	}
+	return Infer(wp, *lat, *lng, at, radiusM) == "arrive"
+}
+
+// Infer aplica una posición GPS del conductor sobre la parada:
+// dentro de radiusM marca la llegada; ya llegado, al alejarse marca la salida.
+// Devuelve "arrive", "depart" o "" si la posición no cambia nada.
+func Infer(wp *domains.Waypoint, lat, lng float64, at time.Time, radiusM int) string {
+	if wp.DepartedAt != nil {
+		return ""
+	}
+
+	distance := optimization.HaversineDistance(lat, lng, wp.Latitude, wp.Longitude) * 1000
+
+	if wp.ArrivedAt == nil {
+		if distance <= float64(radiusM) && Arrive(wp, at, SourceInferred) == nil {
+			return "arrive"
+		}
+		return ""
+	}
+
+	if distance > float64(radiusM)*departureRadiusFactor && Depart(wp, at, SourceInferred) == nil {
+		return "depart"
+	}
+	return ""
+}
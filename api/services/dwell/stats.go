@@ -0,0 +1,115 @@
+package dwell
+
+import (
+	"sort"
+	"time"
+
+	"github.com/tu-usuario/route-manager/api/domains"
+)
+
+// StopStats es el tiempo de servicio de una parada
+type StopStats struct {
+	WaypointID    string     `json:"waypoint_id"`
+	SequenceOrder int        `json:"sequence_order"`
+	ArrivedAt     *time.Time `json:"arrived_at"`
+	DepartedAt    *time.Time `json:"departed_at"`
+	DwellSeconds  *int       `json:"dwell_seconds"`
+	Source        string     `json:"source,omitempty"`
+}
+
+// RouteStats resume los tiempos de una ruta para calibrar la estimación
+type RouteStats struct {
+	StopsMeasured       int      `json:"stops_measured"`
+	TotalDwellSeconds   int      `json:"total_dwell_seconds"`
+	AvgDwellSeconds     float64  `json:"avg_dwell_seconds"`
+	MedianDwellSeconds  float64  `json:"median_dwell_seconds"`
+	MaxDwellSeconds     int      `json:"max_dwell_seconds"`
+	ActualDurationMin   *float64 `json:"actual_duration_min"`    // Primera llegada → última salida
+	EstimatedDuration   int      `json:"estimated_duration_min"` // Lo planificado
+	DrivingMinutes      *float64 `json:"driving_min"`            // Duración real - tiempo de servicio
+	EstimateDeviationPc *float64 `json:"estimate_deviation_pct"`
+
+	Stops []StopStats `json:"stops"`
+}
+
+// ForRoute calcula las estadísticas a partir de las paradas de la ruta
+func ForRoute(route *domains.Route, waypoints []domains.Waypoint) RouteStats {
+	ordered := make([]domains.Waypoint, len(waypoints))
+	copy(ordered, waypoints)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].SequenceOrder < ordered[j].SequenceOrder
+	})
+
+	stats := RouteStats{
+		EstimatedDuration: route.EstimatedDurationMin,
+		Stops:             make([]StopStats, 0, len(ordered)),
+	}
+
+	var dwells []int
+	var firstArrival, lastDeparture *time.Time
+	for _, wp := range ordered {
+		source := wp.ArrivalSource
+		if wp.DepartureSource != "" && wp.DepartureSource != source {
+			source = source + "/" + wp.DepartureSource
+		}
+		stats.Stops = append(stats.Stops, StopStats{
+			WaypointID:    wp.ID.String(),
+			SequenceOrder: wp.SequenceOrder,
+			ArrivedAt:     wp.ArrivedAt,
+			DepartedAt:    wp.DepartedAt,
+			DwellSeconds:  wp.DwellSeconds,
+			Source:        source,
+		})
+
+		if wp.DwellSeconds != nil {
+			dwells = append(dwells, *wp.DwellSeconds)
+		}
+		if wp.ArrivedAt != nil && (firstArrival == nil || wp.ArrivedAt.Before(*firstArrival)) {
+			firstArrival = wp.ArrivedAt
+		}
+		if wp.DepartedAt != nil && (lastDeparture == nil || wp.DepartedAt.After(*lastDeparture)) {
+			lastDeparture = wp.DepartedAt
+		}
+	}
+
+	if len(dwells) > 0 {
+		stats.StopsMeasured = len(dwells)
+		for _, d := range dwells {
+			stats.TotalDwellSeconds += d
+			if d > stats.MaxDwellSeconds {
+				stats.MaxDwellSeconds = d
+			}
+		}
+		stats.AvgDwellSeconds = float64(stats.TotalDwellSeconds) / float64(len(dwells))
+		stats.MedianDwellSeconds = Median(dwells)
+	}
+
+	if firstArrival != nil && lastDeparture != nil && lastDeparture.After(*firstArrival) {
+		actual := lastDeparture.Sub(*firstArrival).Minutes()
+		driving := actual - float64(stats.TotalDwellSeconds)/60
+		stats.ActualDurationMin = &actual
+		stats.DrivingMinutes = &driving
+		if route.EstimatedDurationMin > 0 {
+			deviation := (actual - float64(route.EstimatedDurationMin)) / float64(route.EstimatedDurationMin) * 100
+			stats.EstimateDeviationPc = &deviation
+		}
+	}
+
+	return stats
+}
+
+// Median de una lista de segundos (no modifica la original)
+func Median(values []int) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]int, len(values))
+	copy(sorted, values)
+	sort.Ints(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return float64(sorted[mid-1]+sorted[mid]) / 2
+	}
+	return float64(sorted[mid])
+}
@@ -0,0 +1,44 @@
+package fleet
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+)
+
+// Resolve decide de qué flota se habla: el Admin gestiona la suya y el Super Admin
+// indica ?admin_id=, que debe ser un Admin existente. Si falla, ya respondió al cliente.
+func Resolve(c *gin.Context) (uuid.UUID, bool) {
+	userID, _ := c.Get("userID")
+
+	var user domains.User
+	if err := database.DB.Select("id, role").First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no encontrado"})
+		return uuid.Nil, false
+	}
+
+	if user.Role != "super_admin" {
+		return user.ID, true
+	}
+
+	adminID, err := uuid.Parse(c.Query("admin_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Como Super Admin debes indicar admin_id"})
+		return uuid.Nil, false
+	}
+
+	var count int64
+	if err := database.DB.Model(&domains.User{}).Where("id = ? AND role = ?", adminID, "admin").Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error verificando admin_id"})
+		return uuid.Nil, false
+	}
+	if count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "admin_id no corresponde a un Admin"})
+		return uuid.Nil, false
+	}
+	return adminID, true
+}
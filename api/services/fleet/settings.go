@@ -0,0 +1,21 @@
+package fleet
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+)
+
+// Settings devuelve la política de la flota del Admin (o la de por defecto si nunca la configuró)
+func Settings(adminID uuid.UUID) (domains.FleetSettings, error) {
+	var settings domains.FleetSettings
+	err := database.DB.First(&settings, "admin_id = ?", adminID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domains.DefaultFleetSettings(adminID), nil
+	}
+	return settings, err
+}
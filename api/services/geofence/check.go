@@ -0,0 +1,76 @@
+package geofence
+
+import (
+	"math"
+
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/optimization"
+)
+
+const (
+	Accepted = "accepted"
+	Flagged  = "flagged"
+	Rejected = "rejected"
+
+	ReasonOutsideRadius = "outside_radius"
+	ReasonLowAccuracy   = "low_accuracy"
+	ReasonNoLocation    = "no_location"
+)
+
+// Verdict es el resultado de comparar la posición del conductor con la parada
+type Verdict struct {
+	Result    string   `json:"result"`
+	Reason    string   `json:"reason,omitempty"`
+	DistanceM *float64 `json:"distance_m,omitempty"`
+	RadiusM   int      `json:"radius_m"`
+}
+
+// Evaluate aplica la política de la flota. lat/lng/accuracy pueden ser nil si el
+// dispositivo no reportó posición. La precisión juega a favor del conductor:
+// se acepta si el círculo de incertidumbre alcanza el radio permitido.
+func Evaluate(policy domains.FleetSettings, waypoint *domains.Waypoint, lat, lng, accuracy *float64) Verdict {
+	verdict := Verdict{RadiusM: policy.GeofenceAcceptRadiusM}
+
+	// 1. Sin posición
+	if lat == nil || lng == nil {
+		verdict.Reason = ReasonNoLocation
+		verdict.Result = Flagged
+		if policy.GeofenceRequireLocation {
+			verdict.Result = Rejected
+		}
+		return verdict
+	}
+
+	distance := optimization.HaversineDistance(*lat, *lng, waypoint.Latitude, waypoint.Longitude) * 1000
+	distance = math.Round(distance)
+	verdict.DistanceM = &distance
+
+	margin := 0.0
+	if accuracy != nil {
+		margin = *accuracy
+	}
+
+	// 2. Demasiado lejos incluso descontando la precisión: rechazo duro
+	if policy.GeofenceRejectRadiusM > 0 && distance-margin > float64(policy.GeofenceRejectRadiusM) {
+		verdict.Result = Rejected
+		verdict.Reason = ReasonOutsideRadius
+		return verdict
+	}
+
+	// 3. Fuera del radio permitido: excepción
+	if distance-margin > float64(policy.GeofenceAcceptRadiusM) {
+		verdict.Result = Flagged
+		verdict.Reason = ReasonOutsideRadius
+		return verdict
+	}
+
+	// 4. Dentro, pero con una lectura poco confiable
+	if accuracy != nil && policy.GeofenceMaxAccuracyM > 0 && *accuracy > float64(policy.GeofenceMaxAccuracyM) {
+		verdict.Result = Flagged
+		verdict.Reason = ReasonLowAccuracy
+		return verdict
+	}
+
+	verdict.Result = Accepted
+	return verdict
+}
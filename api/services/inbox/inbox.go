@@ -0,0 +1,122 @@
+package inbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/domains"
+)
+
+// Tipos de aviso de la bandeja
+const (
+	RouteAssigned       = "route.assigned"        // Al conductor: le asignaron una ruta
+	DriverJoined        = "driver.joined"         // Al Admin: un conductor se unió con su código
+	UserPendingApproval = "user.pending_approval" // A los Super Admins: un Admin nuevo espera activación
+	IncidentReported    = "incident.reported"     // Al Admin: un conductor reportó un incidente en su ruta
+	IncidentResolved    = "incident.resolved"     // Al conductor: su incidente se cerró
+	InspectionFailed    = "inspection.failed"     // Al Admin: un checklist pre-viaje tiene ítems críticos fallidos
+)
+
+// Notify deja un aviso en la bandeja del usuario. Usar la tx del cambio que lo origina:
+// si la transacción se revierte, el aviso nunca existió.
+func Notify(tx *gorm.DB, userID uuid.UUID, notificationType, title, body string, data interface{}) error {
+	notification := domains.Notification{
+		UserID: userID,
+		Type:   notificationType,
+		Title:  title,
+		Body:   body,
+	}
+	if data != nil {
+		payload, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		notification.Data = payload
+	}
+	return tx.Create(&notification).Error
+}
+
+// NotifyRouteAssigned avisa al conductor de su nueva ruta
+func NotifyRouteAssigned(tx *gorm.DB, route *domains.Route, driverID uuid.UUID) error {
+	body := fmt.Sprintf("Se te asignó la ruta \"%s\"", route.Name)
+	if route.ScheduledDate != nil {
+		body += " para el " + route.ScheduledDate.Format("02/01/2006 15:04")
+	}
+	return Notify(tx, driverID, RouteAssigned, "Nueva ruta asignada", body, map[string]interface{}{
+		"route_id":       route.ID,
+		"scheduled_date": route.ScheduledDate,
+	})
+}
+
+// NotifyDriverJoined avisa al Admin que un conductor se unió a su flota
+func NotifyDriverJoined(tx *gorm.DB, adminID uuid.UUID, driver *domains.User) error {
+	return Notify(tx, adminID, DriverJoined, "Nuevo conductor en tu flota",
+		fmt.Sprintf("%s se unió a tu flota con tu código", displayName(driver)),
+		map[string]interface{}{"user_id": driver.ID})
+}
+
+// NotifyPendingApproval avisa a todos los Super Admins activos que un registro espera activación
+func NotifyPendingApproval(tx *gorm.DB, user *domains.User) error {
+	var superAdmins []domains.User
+	if err := tx.Select("id").Where("role = ? AND status = ?", "super_admin", "active").Find(&superAdmins).Error; err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("%s se registró como %s y espera activación", displayName(user), user.Role)
+	for _, admin := range superAdmins {
+		if err := Notify(tx, admin.ID, UserPendingApproval, "Registro pendiente de aprobación", body,
+			map[string]interface{}{"user_id": user.ID, "role": user.Role}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NotifyIncidentReported avisa al Admin dueño de la ruta
+func NotifyIncidentReported(tx *gorm.DB, incident *domains.Incident, route *domains.Route) error {
+	return Notify(tx, incident.AdminID, IncidentReported,
+		fmt.Sprintf("Incidente %s en la ruta \"%s\"", incident.Severity, route.Name),
+		incident.Description,
+		map[string]interface{}{
+			"incident_id": incident.ID,
+			"route_id":    incident.RouteID,
+			"waypoint_id": incident.WaypointID,
+			"category":    incident.Category,
+			"severity":    incident.Severity,
+		})
+}
+
+// NotifyIncidentResolved avisa al conductor que reportó el incidente
+func NotifyIncidentResolved(tx *gorm.DB, incident *domains.Incident) error {
+	return Notify(tx, incident.ReporterID, IncidentResolved, "Incidente resuelto", incident.ResolutionNote,
+		map[string]interface{}{"incident_id": incident.ID, "route_id": incident.RouteID})
+}
+
+// NotifyInspectionFailed avisa al Admin de la ruta qué ítems críticos fallaron
+func NotifyInspectionFailed(tx *gorm.DB, submission *domains.InspectionSubmission, route *domains.Route) error {
+	var failed []string
+	for _, answer := range submission.Answers {
+		if answer.Failed && answer.Critical {
+			failed = append(failed, answer.Label)
+		}
+	}
+	return Notify(tx, submission.AdminID, InspectionFailed,
+		fmt.Sprintf("Inspección fallida en la ruta \"%s\"", route.Name),
+		"Ítems críticos: "+strings.Join(failed, ", "),
+		map[string]interface{}{
+			"submission_id": submission.ID,
+			"route_id":      submission.RouteID,
+			"driver_id":     submission.DriverID,
+		})
+}
+
+func displayName(user *domains.User) string {
+	if user.FullName != "" {
+		return user.FullName
+	}
+	return user.Email
+}
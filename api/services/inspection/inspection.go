@@ -0,0 +1,108 @@
+package inspection
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/fleet"
+)
+
+// Motivos por los que una ruta no puede iniciarse
+const (
+	CodeRequired = "INSPECTION_REQUIRED" // Falta el checklist (o la flota no tiene uno activo)
+	CodeFailed   = "INSPECTION_FAILED"   // El último checklist tiene ítems críticos fallidos
+)
+
+// ActiveTemplate devuelve el checklist activo de la flota con sus ítems (nil si no hay ninguno)
+func ActiveTemplate(db *gorm.DB, adminID uuid.UUID) (*domains.ChecklistTemplate, error) {
+	var template domains.ChecklistTemplate
+	err := db.
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		Where("admin_id = ? AND is_active = ?", adminID, true).
+		First(&template).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// Failed indica si la respuesta no supera el ítem.
+// Solo pueden fallar los booleanos ("No") y los números fuera de rango; una respuesta vacía no falla.
+func Failed(item *domains.ChecklistItem, answer *domains.InspectionAnswer) bool {
+	switch item.Type {
+	case domains.ChecklistItemBoolean:
+		return answer.BoolValue != nil && !*answer.BoolValue
+	case domains.ChecklistItemNumber:
+		if answer.NumberValue == nil {
+			return false
+		}
+		value := *answer.NumberValue
+		return (item.MinValue != nil && value < *item.MinValue) || (item.MaxValue != nil && value > *item.MaxValue)
+	default:
+		return false
+	}
+}
+
+// LatestSubmission devuelve el último checklist que el conductor envió para la ruta (nil si no hay)
+func LatestSubmission(db *gorm.DB, routeID, driverID uuid.UUID) (*domains.InspectionSubmission, error) {
+	var submission domains.InspectionSubmission
+	err := db.Where("route_id = ? AND driver_id = ?", routeID, driverID).
+		Order("created_at DESC").
+		First(&submission).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &submission, nil
+}
+
+// StartBlocker revisa si la ruta puede iniciarse. Una inspección con ítems críticos fallidos
+// bloquea siempre; que falte la inspección solo bloquea si la política de la flota la exige.
+// Devuelve code y mensaje vacíos si puede arrancar.
+func StartBlocker(route *domains.Route) (string, string, error) {
+	var submission *domains.InspectionSubmission
+	if route.DriverID != nil {
+		var err error
+		if submission, err = LatestSubmission(database.DB, route.ID, *route.DriverID); err != nil {
+			return "", "", err
+		}
+	}
+	if submission != nil && submission.CriticalFailed > 0 {
+		return CodeFailed, "La última inspección tiene ítems críticos fallidos. Corrige el problema y vuelve a enviarla", nil
+	}
+
+	policy, err := fleet.Settings(route.CreatorID)
+	if err != nil {
+		return "", "", err
+	}
+	if !policy.RequirePreTripInspection {
+		return "", "", nil
+	}
+	if route.DriverID == nil {
+		// Sin conductor no hay quién haya inspeccionado el vehículo
+		return CodeRequired, "La flota exige inspección pre-viaje: asigna un conductor y que complete el checklist antes de iniciar la ruta", nil
+	}
+
+	if submission == nil {
+		template, err := ActiveTemplate(database.DB, route.CreatorID)
+		if err != nil {
+			return "", "", err
+		}
+		if template == nil {
+			return CodeRequired, "La flota exige inspección pre-viaje pero no tiene un checklist activo. Avisa a tu Admin", nil
+		}
+		return CodeRequired, "Completa la inspección pre-viaje antes de iniciar la ruta", nil
+	}
+	return "", "", nil
+}
@@ -0,0 +1,263 @@
+package notify
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/fleet"
+	"github.com/tu-usuario/route-manager/api/utils"
+)
+
+const (
+	// Si la ruta no tiene duración estimada, asumimos este tiempo por parada para el ETA
+	defaultMinutesPerStop = 10
+
+	// Vigencia del link de seguimiento que se crea para el aviso
+	trackingLinkTTL = 72 * time.Hour
+)
+
+// Los avisos se encolan con tx (misma transacción que el cambio que los dispara):
+// si la transacción se revierte, el cliente nunca recibe el mensaje.
+
+// EnqueueRouteStarted: la ruta pasó a in_progress
+func EnqueueRouteStarted(tx *gorm.DB, route *domains.Route) error {
+	return enqueueProgress(tx, route)
+}
+
+// EnqueueStopResolved: la parada se completó o falló; avisa la entrega y avanza la cola
+func EnqueueStopResolved(tx *gorm.DB, route *domains.Route, wp *domains.Waypoint) error {
+	if wp.IsCompleted {
+		job, err := newJob(tx, route)
+		if err != nil {
+			return err
+		}
+		if err := job.enqueue(wp, domains.NotifyDelivered, 0); err != nil {
+			return err
+		}
+	}
+	return enqueueProgress(tx, route)
+}
+
+// enqueueProgress avisa "en camino" a la próxima parada y "faltan N" a la que está N lugares detrás
+func enqueueProgress(tx *gorm.DB, route *domains.Route) error {
+	if route.Status != "in_progress" {
+		return nil
+	}
+
+	var pending []domains.Waypoint
+	if err := tx.Where("route_id = ? AND is_completed = ? AND failed_at IS NULL", route.ID, false).
+		Order("sequence_order ASC").Find(&pending).Error; err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	job, err := newJob(tx, route)
+	if err != nil {
+		return err
+	}
+
+	if err := job.enqueue(&pending[0], domains.NotifyOnTheWay, 0); err != nil {
+		return err
+	}
+	if n := job.settings.CustomerNotifyStopsAway; n > 0 && len(pending) > n {
+		return job.enqueue(&pending[n], domains.NotifyStopsAway, n)
+	}
+	return nil
+}
+
+// job agrupa lo que comparten los avisos de un mismo disparo
+type job struct {
+	tx         *gorm.DB
+	route      *domains.Route
+	settings   domains.FleetSettings
+	driverName string
+	perStop    time.Duration
+	now        time.Time
+}
+
+func newJob(tx *gorm.DB, route *domains.Route) (*job, error) {
+	settings, err := fleet.Settings(route.CreatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &job{
+		tx:         tx,
+		route:      route,
+		settings:   settings,
+		driverName: "Tu conductor",
+		perStop:    time.Duration(defaultMinutesPerStop) * time.Minute,
+		now:        time.Now(),
+	}
+
+	if route.DriverID != nil {
+		var driver domains.User
+		if err := tx.Select("id, full_name").First(&driver, "id = ?", *route.DriverID).Error; err == nil && driver.FullName != "" {
+			j.driverName = driver.FullName
+		}
+	}
+
+	var totalStops int64
+	if err := tx.Model(&domains.Waypoint{}).Where("route_id = ?", route.ID).Count(&totalStops).Error; err != nil {
+		return nil, err
+	}
+	if route.EstimatedDurationMin > 0 && totalStops > 0 {
+		j.perStop = time.Duration(route.EstimatedDurationMin) * time.Minute / time.Duration(totalStops)
+	}
+	return j, nil
+}
+
+// enqueue crea un aviso por canal con contacto (una sola vez por ruta, parada, evento y canal)
+func (j *job) enqueue(wp *domains.Waypoint, event string, stopsAway int) error {
+	for _, channel := range Channels {
+		contact := NormalizeContact(channel, contactFor(wp, channel))
+		if contact == "" || !Enabled(channel) {
+			continue
+		}
+
+		var existing int64
+		if err := j.tx.Model(&domains.CustomerNotification{}).
+			Where("route_id = ? AND waypoint_id = ? AND event = ? AND channel = ?", j.route.ID, wp.ID, event, channel).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			continue
+		}
+
+		tpl, err := TemplateFor(j.tx, j.route.CreatorID, event, channel)
+		if err != nil {
+			return err
+		}
+		if !tpl.IsActive {
+			continue
+		}
+
+		link, err := j.trackingLink(wp)
+		if err != nil {
+			return err
+		}
+
+		eta := ""
+		if event != domains.NotifyDelivered {
+			eta = j.now.Add(time.Duration(stopsAway+1) * j.perStop).Format("15:04")
+		}
+
+		notification := domains.CustomerNotification{
+			AdminID:          j.route.CreatorID,
+			RouteID:          j.route.ID,
+			WaypointID:       wp.ID,
+			Event:            event,
+			Channel:          channel,
+			Recipient:        contact,
+			UnsubscribeToken: utils.GenerateSecureToken(16),
+			Status:           domains.CustomerNotifyPending,
+			NextAttemptAt:    j.now,
+		}
+		vars := map[string]string{
+			"customer_name":    wp.CustomerName,
+			"address":          wp.Address,
+			"eta":              eta,
+			"stops_away":       strconv.Itoa(stopsAway),
+			"driver_name":      j.driverName,
+			"tracking_link":    TrackingURL(link.Token),
+			"unsubscribe_link": UnsubscribeURL(notification.UnsubscribeToken),
+		}
+		if channel == domains.ChannelEmail {
+			notification.Subject = Render(tpl.Subject, vars)
+		}
+		notification.Body = Render(tpl.Body, vars)
+
+		// Si ya se dio de baja queda en el registro, pero no se envía
+		optedOut, err := IsOptedOut(j.tx, j.route.CreatorID, channel, contact)
+		if err != nil {
+			return err
+		}
+		if optedOut {
+			notification.Status = domains.CustomerNotifyOptedOut
+		}
+
+		if err := j.tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&notification).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trackingLink reutiliza un link vigente de la parada o crea uno a nombre del Admin de la ruta
+func (j *job) trackingLink(wp *domains.Waypoint) (*domains.TrackingLink, error) {
+	var link domains.TrackingLink
+	err := j.tx.Where("waypoint_id = ? AND revoked_at IS NULL AND expires_at > ?", wp.ID, j.now.Add(time.Hour)).
+		Order("expires_at DESC").First(&link).Error
+	if err == nil {
+		return &link, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	link = domains.TrackingLink{
+		WaypointID: wp.ID,
+		Token:      utils.GenerateSecureToken(24),
+		CreatedBy:  j.route.CreatorID,
+		ExpiresAt:  j.now.Add(trackingLinkTTL),
+	}
+	if err := j.tx.Create(&link).Error; err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func contactFor(wp *domains.Waypoint, channel string) string {
+	if channel == domains.ChannelEmail {
+		return wp.CustomerEmail
+	}
+	return wp.CustomerPhone
+}
+
+// NormalizeContact deja el contacto en la forma en que se guardan las bajas
+func NormalizeContact(channel, contact string) string {
+	contact = strings.TrimSpace(contact)
+	if channel == domains.ChannelEmail {
+		return strings.ToLower(contact)
+	}
+	return strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(contact)
+}
+
+// IsOptedOut indica si el contacto se dio de baja de los avisos de la flota por ese canal
+func IsOptedOut(db *gorm.DB, adminID uuid.UUID, channel, contact string) (bool, error) {
+	var count int64
+	err := db.Model(&domains.ContactOptOut{}).
+		Where("admin_id = ? AND channel = ? AND contact = ?", adminID, channel, contact).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// TrackingURL arma el link público de seguimiento (front si FRONTEND_URL, si no la API pública)
+func TrackingURL(token string) string {
+	if frontend := strings.TrimRight(os.Getenv("FRONTEND_URL"), "/"); frontend != "" {
+		return frontend + "/track/" + token
+	}
+	if api := strings.TrimRight(os.Getenv("PUBLIC_API_URL"), "/"); api != "" {
+		return api + "/api/v1/track/" + token
+	}
+	return ""
+}
+
+// UnsubscribeURL arma el link de baja (necesita PUBLIC_API_URL: lo atiende la API)
+func UnsubscribeURL(token string) string {
+	if api := strings.TrimRight(os.Getenv("PUBLIC_API_URL"), "/"); api != "" {
+		return api + "/api/v1/unsubscribe/" + token
+	}
+	return ""
+}
@@ -0,0 +1,44 @@
+package notify
+
+import (
+	"os"
+
+	"github.com/tu-usuario/route-manager/api/domains"
+)
+
+// Message es un aviso ya renderizado para un destinatario
+type Message struct {
+	To      string
+	Subject string // Solo email
+	Body    string
+}
+
+// Provider envía mensajes por un canal (email, sms).
+// Un error indica que el envío debe reintentarse más tarde.
+type Provider interface {
+	Channel() string
+	Send(msg Message) error
+}
+
+// ProviderFor devuelve el proveedor configurado para el canal (nil si el canal no está configurado).
+// Se lee del entorno en cada llamada, igual que storage.NewService.
+func ProviderFor(channel string) Provider {
+	switch channel {
+	case domains.ChannelEmail:
+		if os.Getenv("SMTP_HOST") == "" {
+			return nil
+		}
+		return NewSMTPProvider()
+	case domains.ChannelSMS:
+		if os.Getenv("SMS_API_URL") == "" {
+			return nil
+		}
+		return NewHTTPSMSProvider()
+	}
+	return nil
+}
+
+// Enabled indica si hay proveedor para el canal: sin proveedor no se encola nada
+func Enabled(channel string) bool {
+	return ProviderFor(channel) != nil
+}
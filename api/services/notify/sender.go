@@ -0,0 +1,132 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+)
+
+const (
+	sendInterval = 10 * time.Second
+	sendBatch    = 50
+
+	// MaxAttempts: después de esto el aviso queda como "failed"
+	MaxAttempts = 5
+	baseBackoff = time.Minute
+
+	// sendLease: mientras se envía, nadie más (otra instancia) toma el mismo aviso
+	sendLease = time.Minute
+)
+
+// StartSender lanza en segundo plano el envío de los avisos pendientes
+func StartSender() {
+	go func() {
+		ticker := time.NewTicker(sendInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := sendDue(time.Now()); err != nil {
+				log.Printf("⚠️ Avisos a clientes: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// sendDue toma los avisos vencidos (con un "lease" para no duplicarlos entre instancias) y los envía
+func sendDue(now time.Time) error {
+	var due []domains.CustomerNotification
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", domains.CustomerNotifyPending, now).
+			Order("next_attempt_at ASC").Limit(sendBatch).
+			Find(&due).Error; err != nil {
+			return err
+		}
+		if len(due) == 0 {
+			return nil
+		}
+
+		ids := make([]interface{}, 0, len(due))
+		for _, n := range due {
+			ids = append(ids, n.ID)
+		}
+		return tx.Model(&domains.CustomerNotification{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(sendLease)).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range due {
+		send(&due[i])
+	}
+	return nil
+}
+
+// send hace un intento y registra el resultado
+func send(n *domains.CustomerNotification) {
+	updates := map[string]interface{}{}
+
+	if skip, reason := stale(n); skip {
+		updates["status"] = domains.CustomerNotifySkipped
+		updates["last_error"] = reason
+	} else if optedOut, err := IsOptedOut(database.DB, n.AdminID, n.Channel, n.Recipient); err == nil && optedOut {
+		// La baja pudo llegar después de encolar el aviso
+		updates["status"] = domains.CustomerNotifyOptedOut
+	} else {
+		var sendErr error
+		if provider := ProviderFor(n.Channel); provider == nil {
+			sendErr = fmt.Errorf("canal %s sin proveedor configurado", n.Channel)
+		} else {
+			sendErr = provider.Send(Message{To: n.Recipient, Subject: n.Subject, Body: n.Body})
+		}
+
+		n.AttemptCount++
+		updates["attempt_count"] = n.AttemptCount
+		updates["last_error"] = ""
+		if sendErr == nil {
+			updates["status"] = domains.CustomerNotifySent
+			updates["sent_at"] = time.Now()
+		} else {
+			updates["last_error"] = sendErr.Error()
+			if n.AttemptCount >= MaxAttempts {
+				updates["status"] = domains.CustomerNotifyFailed
+				log.Printf("💀 Aviso %s (%s) a %s agotó los reintentos: %v", n.Event, n.Channel, n.Recipient, sendErr)
+			} else {
+				updates["next_attempt_at"] = time.Now().Add(backoff(n.AttemptCount))
+			}
+		}
+	}
+
+	if err := database.DB.Model(&domains.CustomerNotification{}).Where("id = ?", n.ID).Updates(updates).Error; err != nil {
+		log.Printf("⚠️ Error registrando aviso %s: %v", n.ID, err)
+	}
+}
+
+// stale: "en camino" / "faltan N" de una parada que ya se resolvió (o se borró) no tiene sentido enviarlo
+func stale(n *domains.CustomerNotification) (bool, string) {
+	if n.Event == domains.NotifyDelivered {
+		return false, ""
+	}
+
+	var wp domains.Waypoint
+	if err := database.DB.Select("id, is_completed, failed_at").First(&wp, "id = ?", n.WaypointID).Error; err != nil {
+		return true, "la parada ya no existe"
+	}
+	if wp.IsCompleted || wp.FailedAt != nil {
+		return true, "la parada ya se resolvió"
+	}
+	return false, ""
+}
+
+// backoff exponencial: 1m, 2m, 4m, 8m...
+func backoff(attempt int) time.Duration {
+	return baseBackoff << uint(attempt-1)
+}
@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/tu-usuario/route-manager/api/domains"
+)
+
+// HTTPSMSProvider es un backend SMS genérico: hace POST JSON {to, from, body} a SMS_API_URL
+// (con "Authorization: Bearer SMS_API_TOKEN" si está definido). Cualquier 2xx es éxito,
+// así que sirve para la mayoría de gateways o para un pequeño adaptador propio.
+type HTTPSMSProvider struct {
+	apiURL string
+	token  string
+	from   string
+}
+
+func NewHTTPSMSProvider() *HTTPSMSProvider {
+	return &HTTPSMSProvider{
+		apiURL: os.Getenv("SMS_API_URL"),
+		token:  os.Getenv("SMS_API_TOKEN"),
+		from:   os.Getenv("SMS_FROM"),
+	}
+}
+
+func (p *HTTPSMSProvider) Channel() string { return domains.ChannelSMS }
+
+func (p *HTTPSMSProvider) Send(msg Message) error {
+	payload, err := json.Marshal(map[string]string{
+		"to":   msg.To,
+		"from": p.from,
+		"body": msg.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.apiURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error de red sms: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("error sms (%d): %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
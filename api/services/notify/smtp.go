@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"time"
+
+	"github.com/tu-usuario/route-manager/api/domains"
+)
+
+// SMTPProvider envía email por SMTP. Sin usuario no se autentica,
+// así funciona contra un sink local (MailHog, Mailpit: SMTP_HOST=localhost SMTP_PORT=1025).
+type SMTPProvider struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+func NewSMTPProvider() *SMTPProvider {
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	return &SMTPProvider{
+		host:     os.Getenv("SMTP_HOST"),
+		port:     port,
+		username: os.Getenv("SMTP_USER"),
+		password: os.Getenv("SMTP_PASSWORD"),
+		from:     os.Getenv("SMTP_FROM"),
+	}
+}
+
+func (p *SMTPProvider) Channel() string { return domains.ChannelEmail }
+
+func (p *SMTPProvider) Send(msg Message) error {
+	if p.from == "" {
+		return fmt.Errorf("SMTP_FROM no configurado")
+	}
+
+	var auth smtp.Auth
+	if p.username != "" {
+		auth = smtp.PlainAuth("", p.username, p.password, p.host)
+	}
+
+	// smtp.SendMail usa STARTTLS si el servidor lo ofrece
+	addr := net.JoinHostPort(p.host, p.port)
+	if err := smtp.SendMail(addr, auth, p.from, []string{msg.To}, p.build(msg)); err != nil {
+		return fmt.Errorf("error smtp: %v", err)
+	}
+	return nil
+}
+
+// build arma el mensaje RFC 5322 en texto plano UTF-8
+func (p *SMTPProvider) build(msg Message) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", p.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(msg.Body)
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/domains"
+)
+
+// Eventos y canales soportados
+var (
+	Events   = []string{domains.NotifyOnTheWay, domains.NotifyStopsAway, domains.NotifyDelivered}
+	Channels = []string{domains.ChannelEmail, domains.ChannelSMS}
+)
+
+// Placeholders disponibles en asunto y cuerpo
+var Placeholders = []string{
+	"{{customer_name}}", "{{address}}", "{{eta}}", "{{stops_away}}",
+	"{{driver_name}}", "{{tracking_link}}", "{{unsubscribe_link}}",
+}
+
+// IsEvent / IsChannel validan los parámetros de las plantillas
+func IsEvent(name string) bool   { return contains(Events, name) }
+func IsChannel(name string) bool { return contains(Channels, name) }
+
+func contains(list []string, name string) bool {
+	for _, v := range list {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultTemplates: lo que recibe el cliente si la flota no personalizó el aviso
+var defaultTemplates = map[string]domains.NotificationTemplate{
+	domains.NotifyOnTheWay + "/" + domains.ChannelEmail: {
+		Subject: "Tu pedido va en camino",
+		Body: "Hola {{customer_name}},\n\n{{driver_name}} va en camino a {{address}}. Llegada estimada: {{eta}}.\n\n" +
+			"Sigue tu entrega aquí: {{tracking_link}}\n\nSi no quieres recibir más avisos: {{unsubscribe_link}}",
+	},
+	domains.NotifyOnTheWay + "/" + domains.ChannelSMS: {
+		Body: "Tu pedido va en camino (llegada aprox. {{eta}}). Síguelo: {{tracking_link}} Baja: {{unsubscribe_link}}",
+	},
+	domains.NotifyStopsAway + "/" + domains.ChannelEmail: {
+		Subject: "Tu pedido está cerca",
+		Body: "Hola {{customer_name}},\n\nFaltan {{stops_away}} paradas para tu entrega en {{address}}. Llegada estimada: {{eta}}.\n\n" +
+			"Sigue tu entrega aquí: {{tracking_link}}\n\nSi no quieres recibir más avisos: {{unsubscribe_link}}",
+	},
+	domains.NotifyStopsAway + "/" + domains.ChannelSMS: {
+		Body: "Faltan {{stops_away}} paradas para tu entrega (aprox. {{eta}}). Síguela: {{tracking_link}} Baja: {{unsubscribe_link}}",
+	},
+	domains.NotifyDelivered + "/" + domains.ChannelEmail: {
+		Subject: "Tu pedido fue entregado",
+		Body: "Hola {{customer_name}},\n\nTu pedido fue entregado en {{address}}.\n\n" +
+			"Detalle y prueba de entrega: {{tracking_link}}\n\nSi no quieres recibir más avisos: {{unsubscribe_link}}",
+	},
+	domains.NotifyDelivered + "/" + domains.ChannelSMS: {
+		Body: "Tu pedido fue entregado. Detalle: {{tracking_link}} Baja: {{unsubscribe_link}}",
+	},
+}
+
+// DefaultTemplate devuelve la plantilla de fábrica (activa) para el evento y canal
+func DefaultTemplate(adminID uuid.UUID, event, channel string) domains.NotificationTemplate {
+	tpl := defaultTemplates[event+"/"+channel]
+	tpl.AdminID = adminID
+	tpl.Event = event
+	tpl.Channel = channel
+	tpl.IsActive = true
+	return tpl
+}
+
+// TemplateFor devuelve la plantilla de la flota o la de fábrica si nunca la personalizó
+func TemplateFor(db *gorm.DB, adminID uuid.UUID, event, channel string) (domains.NotificationTemplate, error) {
+	var tpl domains.NotificationTemplate
+	err := db.First(&tpl, "admin_id = ? AND event = ? AND channel = ?", adminID, event, channel).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return DefaultTemplate(adminID, event, channel), nil
+	}
+	return tpl, err
+}
+
+// Render reemplaza los placeholders; los desconocidos quedan tal cual
+func Render(text string, vars map[string]string) string {
+	pairs := make([]string, 0, len(vars)*2)
+	for key, value := range vars {
+		pairs = append(pairs, "{{"+key+"}}", value)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
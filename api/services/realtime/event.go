@@ -0,0 +1,59 @@
+package realtime
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/tu-usuario/route-manager/api/domains"
+)
+
+// Tipos de evento
+const (
+	RouteStatus       = "route.status"       // Cambio de estado de la ruta
+	RouteAssigned     = "route.assigned"     // Conductor asignado / reasignado
+	WaypointCompleted = "waypoint.completed" // Entrega completada
+	WaypointFailed    = "waypoint.failed"    // Intento fallido
+	WaypointReopened  = "waypoint.reopened"  // Entrega deshecha o reabierta
+	DriverPosition    = "driver.position"    // Nueva posición del conductor
+	MessageCreated    = "message.created"    // Mensaje nuevo en un hilo de la ruta
+	MessagesRead      = "message.read"       // Un participante leyó el hilo
+	IncidentReported  = "incident.reported"  // El conductor reportó un incidente
+	IncidentUpdated   = "incident.updated"   // El Admin tomó o resolvió un incidente
+)
+
+// Event es lo que se empuja a los clientes. OwnerID/DriverID viajan con el evento
+// para poder filtrar por rol en cualquier instancia (ver Backend).
+type Event struct {
+	Type     string      `json:"type"`
+	RouteID  uuid.UUID   `json:"route_id"`
+	OwnerID  uuid.UUID   `json:"owner_id"`
+	DriverID *uuid.UUID  `json:"driver_id,omitempty"`
+	Data     interface{} `json:"data,omitempty"`
+	At       time.Time   `json:"at"`
+}
+
+// RouteEvent arma un evento sobre una ruta (el dueño y el conductor salen de la ruta)
+func RouteEvent(eventType string, route *domains.Route, data interface{}) Event {
+	return Event{
+		Type:     eventType,
+		RouteID:  route.ID,
+		OwnerID:  route.CreatorID,
+		DriverID: route.DriverID,
+		Data:     data,
+		At:       time.Now(),
+	}
+}
+
+// CanSee aplica las mismas reglas que el listado de rutas:
+// super_admin ve todo, admin las rutas que creó, conductor las que tiene asignadas
+func CanSee(user *domains.User, e Event) bool {
+	switch user.Role {
+	case "super_admin":
+		return true
+	case "admin":
+		return e.OwnerID == user.ID
+	default:
+		return e.DriverID != nil && *e.DriverID == user.ID
+	}
+}
@@ -0,0 +1,117 @@
+package realtime
+
+import (
+	"log"
+	"sync"
+)
+
+// subscriberBuffer: eventos que se encolan por cliente antes de empezar a descartar
+const subscriberBuffer = 64
+
+// Backend transporta los eventos entre instancias. Publish los envía a todas
+// (incluida esta) y Start recibe los que llegan para entregarlos a los clientes locales.
+// MemoryBackend sirve para una sola instancia; para varias, implementar uno sobre
+// Redis pub/sub o Postgres LISTEN/NOTIFY y configurarlo con Use.
+type Backend interface {
+	Publish(e Event) error
+	Start(deliver func(Event))
+}
+
+// MemoryBackend entrega los eventos en el mismo proceso
+type MemoryBackend struct {
+	deliver func(Event)
+}
+
+func (b *MemoryBackend) Start(deliver func(Event)) {
+	b.deliver = deliver
+}
+
+func (b *MemoryBackend) Publish(e Event) error {
+	if b.deliver != nil {
+		b.deliver(e)
+	}
+	return nil
+}
+
+// Subscriber es un cliente conectado. Los eventos llegan por C.
+type Subscriber struct {
+	C      <-chan Event
+	ch     chan Event
+	filter func(Event) bool
+}
+
+// Hub reparte los eventos entre los clientes conectados a esta instancia
+type Hub struct {
+	mu      sync.RWMutex
+	subs    map[*Subscriber]struct{}
+	backend Backend
+}
+
+func NewHub(backend Backend) *Hub {
+	h := &Hub{subs: map[*Subscriber]struct{}{}, backend: backend}
+	backend.Start(h.dispatch)
+	return h
+}
+
+func (h *Hub) Publish(e Event) {
+	if err := h.backend.Publish(e); err != nil {
+		log.Printf("⚠️ Error publicando evento %s: %v", e.Type, err)
+	}
+}
+
+// Subscribe registra un cliente que recibe solo los eventos que pasan filter
+func (h *Hub) Subscribe(filter func(Event) bool) *Subscriber {
+	ch := make(chan Event, subscriberBuffer)
+	s := &Subscriber{C: ch, ch: ch, filter: filter}
+
+	h.mu.Lock()
+	h.subs[s] = struct{}{}
+	h.mu.Unlock()
+	return s
+}
+
+func (h *Hub) Unsubscribe(s *Subscriber) {
+	h.mu.Lock()
+	if _, ok := h.subs[s]; ok {
+		delete(h.subs, s)
+		close(s.ch)
+	}
+	h.mu.Unlock()
+}
+
+// dispatch nunca bloquea: si un cliente no lee a tiempo, pierde el evento
+// (el front debe recargar el estado al reconectarse)
+func (h *Hub) dispatch(e Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for s := range h.subs {
+		if !s.filter(e) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+		}
+	}
+}
+
+var defaultHub = NewHub(&MemoryBackend{})
+
+// Use reemplaza el backend (llamar al arrancar, antes de aceptar conexiones)
+func Use(backend Backend) {
+	defaultHub = NewHub(backend)
+}
+
+// Publish envía el evento por el hub por defecto. Llamar después de confirmar la transacción.
+func Publish(e Event) {
+	defaultHub.Publish(e)
+}
+
+func Subscribe(filter func(Event) bool) *Subscriber {
+	return defaultHub.Subscribe(filter)
+}
+
+func Unsubscribe(s *Subscriber) {
+	defaultHub.Unsubscribe(s)
+}
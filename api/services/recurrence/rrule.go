@@ -0,0 +1,258 @@
+package recurrence
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Subconjunto soportado de RFC 5545 (RRULE):
+//   FREQ=DAILY|WEEKLY|MONTHLY  (obligatorio)
+//   INTERVAL=n                 (por defecto 1)
+//   BYDAY=MO,TU,WE,TH,FR,SA,SU (sin ordinales, ej: no "1MO")
+//   BYMONTHDAY=1,15,-1         (solo con FREQ=MONTHLY)
+//   COUNT=n | UNTIL=YYYYMMDD[THHMMSSZ]
+// Ejemplo: "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"
+
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+)
+
+// maxSpanDays limita la iteración para evitar bucles eternos con reglas mal formadas
+const maxSpanDays = 366 * 10
+
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// Rule representa una RRULE ya parseada
+type Rule struct {
+	Freq       string
+	Interval   int
+	ByDay      []time.Weekday
+	ByMonthDay []int
+	Count      int
+	Until      *time.Time
+}
+
+// Parse convierte un string RRULE en una Rule validada
+func Parse(rrule string) (*Rule, error) {
+	rrule = strings.TrimPrefix(strings.TrimSpace(rrule), "RRULE:")
+	if rrule == "" {
+		return nil, fmt.Errorf("regla de recurrencia vacía")
+	}
+
+	rule := &Rule{Interval: 1}
+
+	for _, part := range strings.Split(rrule, ";") {
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("segmento inválido: %q", part)
+		}
+		key, value := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+
+		switch key {
+		case "FREQ":
+			if value != FreqDaily && value != FreqWeekly && value != FreqMonthly {
+				return nil, fmt.Errorf("FREQ no soportada: %s", value)
+			}
+			rule.Freq = value
+
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("INTERVAL inválido: %s", value)
+			}
+			rule.Interval = n
+
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				wd, ok := weekdayCodes[code]
+				if !ok {
+					return nil, fmt.Errorf("BYDAY inválido: %s", code)
+				}
+				rule.ByDay = append(rule.ByDay, wd)
+			}
+
+		case "BYMONTHDAY":
+			for _, raw := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(raw)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("BYMONTHDAY inválido: %s", raw)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, n)
+			}
+
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("COUNT inválido: %s", value)
+			}
+			rule.Count = n
+
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = &until
+
+		default:
+			return nil, fmt.Errorf("parámetro no soportado: %s", key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("FREQ es requerido")
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, fmt.Errorf("COUNT y UNTIL no pueden usarse juntos")
+	}
+	if len(rule.ByMonthDay) > 0 && rule.Freq != FreqMonthly {
+		return nil, fmt.Errorf("BYMONTHDAY solo es válido con FREQ=MONTHLY")
+	}
+
+	return rule, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			if layout == "20060102" {
+				// Fecha sin hora: incluimos el día completo
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("UNTIL inválido: %s", value)
+}
+
+// Between devuelve las ocurrencias de la regla dentro de [from, to].
+// dtstart define la primera ocurrencia posible y la hora del día de todas ellas.
+func (r *Rule) Between(dtstart, from, to time.Time) []time.Time {
+	var result []time.Time
+	if to.Before(from) {
+		return result
+	}
+
+	emitted := 0
+	day := dateOnly(dtstart)
+
+	for i := 0; i <= maxSpanDays; i++ {
+		occurrence := time.Date(day.Year(), day.Month(), day.Day(),
+			dtstart.Hour(), dtstart.Minute(), dtstart.Second(), 0, dtstart.Location())
+
+		if occurrence.After(to) {
+			break
+		}
+		if r.Until != nil && occurrence.After(*r.Until) {
+			break
+		}
+
+		if !occurrence.Before(dtstart) && r.matches(dtstart, day) {
+			emitted++
+			if r.Count > 0 && emitted > r.Count {
+				break
+			}
+			if !occurrence.Before(from) {
+				result = append(result, occurrence)
+			}
+		}
+
+		day = day.AddDate(0, 0, 1)
+	}
+
+	return result
+}
+
+// matches indica si el día cae dentro del patrón (frecuencia + intervalo + filtros)
+func (r *Rule) matches(dtstart, day time.Time) bool {
+	start := dateOnly(dtstart)
+
+	switch r.Freq {
+	case FreqDaily:
+		days := daysBetween(start, day)
+		if days%r.Interval != 0 {
+			return false
+		}
+		return len(r.ByDay) == 0 || containsWeekday(r.ByDay, day.Weekday())
+
+	case FreqWeekly:
+		weeks := daysBetween(weekStart(start), weekStart(day)) / 7
+		if weeks%r.Interval != 0 {
+			return false
+		}
+		if len(r.ByDay) == 0 {
+			return day.Weekday() == start.Weekday()
+		}
+		return containsWeekday(r.ByDay, day.Weekday())
+
+	case FreqMonthly:
+		months := (day.Year()-start.Year())*12 + int(day.Month()) - int(start.Month())
+		if months%r.Interval != 0 {
+			return false
+		}
+		if len(r.ByMonthDay) > 0 {
+			return matchesMonthDay(r.ByMonthDay, day)
+		}
+		if len(r.ByDay) > 0 {
+			return containsWeekday(r.ByDay, day.Weekday())
+		}
+		return day.Day() == start.Day()
+	}
+
+	return false
+}
+
+func matchesMonthDay(monthDays []int, day time.Time) bool {
+	lastDay := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, day.Location()).Day()
+	for _, md := range monthDays {
+		target := md
+		if md < 0 {
+			// -1 = último día del mes, -2 = penúltimo...
+			target = lastDay + md + 1
+		}
+		if day.Day() == target {
+			return true
+		}
+	}
+	return false
+}
+
+func containsWeekday(days []time.Weekday, wd time.Weekday) bool {
+	for _, d := range days {
+		if d == wd {
+			return true
+		}
+	}
+	return false
+}
+
+// daysBetween redondea para no romperse con los cambios de horario (días de 23/25h)
+func daysBetween(from, to time.Time) int {
+	return int(math.Round(to.Sub(from).Hours() / 24))
+}
+
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// weekStart devuelve el lunes de la semana (WKST=MO)
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return dateOnly(t).AddDate(0, 0, -offset)
+}
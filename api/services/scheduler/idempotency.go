@@ -0,0 +1,26 @@
+package scheduler
+
+import (
+	"log"
+	"time"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+)
+
+const idempotencyCleanupInterval = time.Hour
+
+// StartIdempotencyCleanup borra cada hora las claves de idempotencia vencidas
+func StartIdempotencyCleanup() {
+	go func() {
+		ticker := time.NewTicker(idempotencyCleanupInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			result := database.DB.Where("expires_at < ?", time.Now()).Delete(&domains.IdempotencyKey{})
+			if result.Error != nil {
+				log.Printf("⚠️ Limpieza de claves de idempotencia: %v", result.Error)
+			}
+		}
+	}()
+}
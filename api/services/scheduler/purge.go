@@ -0,0 +1,217 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/audit"
+)
+
+const purgeInterval = 24 * time.Hour
+
+// trashRetention es la retención vigente (la usa la papelera para informar la fecha de purga)
+var trashRetention = 30 * 24 * time.Hour
+
+// TrashRetention devuelve cuánto tiempo permanece un elemento en la papelera
+func TrashRetention() time.Duration {
+	return trashRetention
+}
+
+// StartTrashPurger lanza en segundo plano la purga diaria de la papelera
+func StartTrashPurger(retentionDays int) {
+	trashRetention = time.Duration(retentionDays) * 24 * time.Hour
+
+	go func() {
+		ticker := time.NewTicker(purgeInterval)
+		defer ticker.Stop()
+
+		for {
+			routes, users, err := PurgeTrash(time.Now().Add(-trashRetention))
+			if err != nil {
+				log.Printf("⚠️ Purga de papelera: %v", err)
+			} else if routes > 0 || users > 0 {
+				log.Printf("🗑️ Purga de papelera: %d rutas y %d usuarios", routes, users)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// PurgeTrash elimina definitivamente lo que se borró antes de cutoff.
+// Rutas: se borran físicamente junto con sus paradas.
+// Usuarios: se anonimizan (la auditoría y las rutas históricas siguen apuntando a ellos).
+func PurgeTrash(cutoff time.Time) (int, int, error) {
+	routes, err := purgeRoutes(cutoff)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error purgando rutas: %v", err)
+	}
+
+	users, err := purgeUsers(cutoff)
+	if err != nil {
+		return routes, 0, fmt.Errorf("error purgando usuarios: %v", err)
+	}
+
+	return routes, users, nil
+}
+
+func purgeRoutes(cutoff time.Time) (int, error) {
+	var expired []domains.Route
+	if err := database.DB.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Find(&expired).Error; err != nil {
+		return 0, err
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(expired))
+	for _, r := range expired {
+		ids = append(ids, r.ID)
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		waypointIDs := tx.Unscoped().Model(&domains.Waypoint{}).Select("id").Where("route_id IN ?", ids)
+		if err := tx.Where("waypoint_id IN (?)", waypointIDs).Delete(&domains.TrackingLink{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("waypoint_id IN (?)", waypointIDs).Delete(&domains.DeliveryAttempt{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("waypoint_id IN (?)", waypointIDs).Delete(&domains.SyncOperation{}).Error; err != nil {
+			return err
+		}
+		proofIDs := tx.Model(&domains.ProofOfDelivery{}).Select("id").Where("waypoint_id IN (?)", waypointIDs)
+		if err := tx.Where("proof_id IN (?)", proofIDs).Delete(&domains.ProofPhoto{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("waypoint_id IN (?)", waypointIDs).Delete(&domains.ProofOfDelivery{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("route_id IN ?", ids).Delete(&domains.Breadcrumb{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("route_id IN ?", ids).Delete(&domains.CustomerNotification{}).Error; err != nil {
+			return err
+		}
+		messageIDs := tx.Model(&domains.RouteMessage{}).Select("id").Where("route_id IN ?", ids)
+		if err := tx.Where("message_id IN (?)", messageIDs).Delete(&domains.MessageReceipt{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("route_id IN ?", ids).Delete(&domains.RouteMessage{}).Error; err != nil {
+			return err
+		}
+		incidentIDs := tx.Model(&domains.Incident{}).Select("id").Where("route_id IN ?", ids)
+		if err := tx.Where("incident_id IN (?)", incidentIDs).Delete(&domains.IncidentPhoto{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("route_id IN ?", ids).Delete(&domains.Incident{}).Error; err != nil {
+			return err
+		}
+		submissionIDs := tx.Model(&domains.InspectionSubmission{}).Select("id").Where("route_id IN ?", ids)
+		if err := tx.Where("submission_id IN (?)", submissionIDs).Delete(&domains.InspectionAnswer{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("route_id IN ?", ids).Delete(&domains.InspectionSubmission{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("route_id IN ?", ids).Delete(&domains.Waypoint{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("id IN ?", ids).Delete(&domains.Route{}).Error; err != nil {
+			return err
+		}
+
+		actor := audit.System()
+		for i := range expired {
+			if err := audit.Record(tx, actor, audit.RouteEntry("purge", &expired[i], nil)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(expired), nil
+}
+
+// personalFields son los datos personales del usuario (claves del JSON) que la purga borra
+var personalFields = []string{"email", "full_name", "avatar_url", "fleet_code", "home_latitude", "home_longitude"}
+
+func purgeUsers(cutoff time.Time) (int, error) {
+	var expired []domains.User
+	if err := database.DB.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ? AND purged_at IS NULL", cutoff).
+		Find(&expired).Error; err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	actor := audit.System()
+	purged := 0
+	for i := range expired {
+		user := &expired[i]
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			// Datos operativos que ya no sirven
+			if err := tx.Where("driver_id = ?", user.ID).Delete(&domains.WorkingHours{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("driver_id = ?", user.ID).Delete(&domains.TimeOff{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("user_id = ?", user.ID).Delete(&domains.SyncOperation{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("user_id = ?", user.ID).Delete(&domains.Notification{}).Error; err != nil {
+				return err
+			}
+
+			// Anonimizar datos personales (el email es único: lo derivamos del ID)
+			if err := tx.Unscoped().Model(&domains.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+				"email":          fmt.Sprintf("purged-%s@invalid", user.ID),
+				"full_name":      "Usuario eliminado",
+				"avatar_url":     "",
+				"fleet_code":     nil,
+				"calendar_token": nil,
+				"home_latitude":  nil,
+				"home_longitude": nil,
+				"purged_at":      now,
+			}).Error; err != nil {
+				return err
+			}
+
+			// El historial del usuario (alta, ediciones, baja) también guarda esos datos
+			if err := tx.Model(&domains.AuditLog{}).
+				Where("entity_type = ? AND entity_id = ?", audit.EntityUser, user.ID).
+				Update("changes", gorm.Expr("changes - ?::text[]", "{"+strings.Join(personalFields, ",")+"}")).Error; err != nil {
+				return err
+			}
+
+			// La entrada de la purga solo deja constancia de qué usuario y cuándo
+			entry := audit.UserEntry("purge", user, nil)
+			entry.Before = nil
+			entry.Extra = map[string]audit.Change{
+				"id":        {From: nil, To: user.ID},
+				"purged_at": {From: nil, To: now},
+			}
+			return audit.Record(tx, actor, entry)
+		})
+		if err != nil {
+			// Un usuario que falla no debe frenar a los demás
+			log.Printf("⚠️ Purga de usuario %s: %v", user.ID, err)
+			continue
+		}
+		purged++
+	}
+
+	return purged, nil
+}
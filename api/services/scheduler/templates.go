@@ -0,0 +1,207 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/audit"
+	"github.com/tu-usuario/route-manager/api/services/availability"
+	"github.com/tu-usuario/route-manager/api/services/optimization"
+	"github.com/tu-usuario/route-manager/api/services/recurrence"
+	"github.com/tu-usuario/route-manager/api/services/webhooks"
+)
+
+// StartTemplateScheduler lanza en segundo plano la generación periódica de rutas
+// a partir de las plantillas activas. Se ejecuta una vez al arrancar y luego cada interval.
+func StartTemplateScheduler(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			created, err := MaterializeAll(time.Now())
+			if err != nil {
+				log.Printf("⚠️ Scheduler de plantillas: %v", err)
+			} else if created > 0 {
+				log.Printf("🗓️ Scheduler de plantillas: %d rutas generadas", created)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// MaterializeAll recorre todas las plantillas activas y genera las rutas que falten
+func MaterializeAll(now time.Time) (int, error) {
+	var templates []domains.RouteTemplate
+	if err := database.DB.Preload("Waypoints").Where("is_active = ?", true).Find(&templates).Error; err != nil {
+		return 0, fmt.Errorf("error listando plantillas: %v", err)
+	}
+
+	total := 0
+	for i := range templates {
+		created, err := MaterializeTemplate(&templates[i], now, audit.System())
+		if err != nil {
+			// Una plantilla rota no debe frenar a las demás
+			log.Printf("⚠️ Plantilla %s: %v", templates[i].ID, err)
+			continue
+		}
+		total += len(created)
+	}
+
+	return total, nil
+}
+
+// MaterializeTemplate genera las rutas de la plantilla para los próximos DaysAhead días.
+// Es idempotente: si ya existe una ruta para esa plantilla y día, no la duplica
+// (el índice único de template_id + template_date lo garantiza aunque corran dos a la vez).
+// La plantilla debe venir con sus Waypoints precargados; actor queda en la auditoría.
+func MaterializeTemplate(tpl *domains.RouteTemplate, now time.Time, actor audit.Actor) ([]domains.Route, error) {
+	rule, err := recurrence.Parse(tpl.RRule)
+	if err != nil {
+		return nil, err
+	}
+
+	daysAhead := tpl.DaysAhead
+	if daysAhead <= 0 {
+		daysAhead = 7
+	}
+	horizon := now.AddDate(0, 0, daysAhead)
+
+	created := []domains.Route{}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, occurrence := range rule.Between(tpl.StartsAt, now, horizon) {
+			// Unscoped: si el admin borró la ruta generada, no la volvemos a crear
+			day := templateDay(occurrence)
+			var existing int64
+			if err := tx.Unscoped().Model(&domains.Route{}).
+				Where("template_id = ? AND template_date = ?", tpl.ID, day).
+				Count(&existing).Error; err != nil {
+				return err
+			}
+			if existing > 0 {
+				continue
+			}
+
+			route := buildRouteFromTemplate(tpl, occurrence)
+
+			// Mismo criterio que la asignación automática: si el conductor por defecto
+			// está de licencia o no trabaja ese día, la ruta queda sin asignar
+			var unassigned []string
+			if route.DriverID != nil {
+				avail, err := availability.Check(*route.DriverID, route.ScheduledDate, route.EstimatedDurationMin)
+				if err != nil {
+					return fmt.Errorf("error verificando disponibilidad del %s: %v", occurrence.Format("2006-01-02"), err)
+				}
+				if !avail.Available {
+					route.DriverID = nil
+					unassigned = avail.Blocking
+				}
+			}
+
+			// Otra instancia (o un "generar ahora") pudo crearla recién: en ese caso no se hace nada
+			res := tx.Clauses(clause.OnConflict{DoNothing: true}).Omit(clause.Associations).Create(&route)
+			if res.Error != nil {
+				return fmt.Errorf("error creando ruta del %s: %v", occurrence.Format("2006-01-02"), res.Error)
+			}
+			if res.RowsAffected == 0 {
+				continue
+			}
+			for i := range route.Waypoints {
+				route.Waypoints[i].RouteID = route.ID
+			}
+			if len(route.Waypoints) > 0 {
+				if err := tx.Create(&route.Waypoints).Error; err != nil {
+					return fmt.Errorf("error creando paradas del %s: %v", occurrence.Format("2006-01-02"), err)
+				}
+			}
+
+			entry := audit.RouteEntry("create", nil, &route)
+			entry.Extra = map[string]audit.Change{"template_id": {From: nil, To: tpl.ID}}
+			if unassigned != nil {
+				entry.Extra["default_driver_skipped"] = audit.Change{From: tpl.DefaultDriverID, To: unassigned}
+			}
+			if err := audit.Record(tx, actor, entry); err != nil {
+				return err
+			}
+			if err := webhooks.EnqueueRouteCreated(tx, &route); err != nil {
+				return err
+			}
+			created = append(created, route)
+		}
+
+		generatedAt := time.Now()
+		return tx.Model(tpl).Update("last_generated_at", generatedAt).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// templateDay es el día local de la ocurrencia (columna date: sin hora ni zona)
+func templateDay(occurrence time.Time) time.Time {
+	local := occurrence.In(time.Local)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// buildRouteFromTemplate arma una Route nueva (con IDs frescos) a partir del molde
+func buildRouteFromTemplate(tpl *domains.RouteTemplate, occurrence time.Time) domains.Route {
+	waypoints := make([]domains.Waypoint, 0, len(tpl.Waypoints))
+	for _, twp := range tpl.Waypoints {
+		waypoints = append(waypoints, domains.Waypoint{
+			ID:            uuid.New(),
+			Address:       twp.Address,
+			Latitude:      twp.Latitude,
+			Longitude:     twp.Longitude,
+			SequenceOrder: twp.SequenceOrder,
+			CustomerName:  twp.CustomerName,
+			CustomerEmail: twp.CustomerEmail,
+			CustomerPhone: twp.CustomerPhone,
+			Notes:         twp.Notes,
+			IsCompleted:   false,
+		})
+	}
+
+	totalDistance := tpl.TotalDistanceKm
+
+	// Pre-optimización (mismo criterio que OptimizeRoute: mínimo 3 puntos)
+	if tpl.AutoOptimize && len(waypoints) >= 3 {
+		waypoints = optimization.OptimizeRoute(waypoints)
+		for i := range waypoints {
+			waypoints[i].SequenceOrder = i + 1
+		}
+		totalDistance = optimization.CalculateRouteDistance(waypoints)
+	}
+
+	status := tpl.TargetStatus
+	if status == "" {
+		status = "draft"
+	}
+
+	scheduled := occurrence
+	day := templateDay(occurrence)
+	templateID := tpl.ID
+
+	return domains.Route{
+		ID:                   uuid.New(),
+		CreatorID:            tpl.CreatorID,
+		DriverID:             tpl.DefaultDriverID,
+		TemplateID:           &templateID,
+		TemplateDate:         &day,
+		Name:                 tpl.Name,
+		Status:               status,
+		ScheduledDate:        &scheduled,
+		TotalDistanceKm:      totalDistance,
+		EstimatedDurationMin: tpl.EstimatedDurationMin,
+		Waypoints:            waypoints,
+	}
+}
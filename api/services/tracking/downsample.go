@@ -0,0 +1,83 @@
+package tracking
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/tu-usuario/route-manager/api/domains"
+	"github.com/tu-usuario/route-manager/api/services/optimization"
+)
+
+// Criterios de reducción: un punto se guarda si el conductor se movió lo suficiente,
+// si giró, o si pasó demasiado tiempo desde el último guardado (para ver las paradas)
+const (
+	MaxAccuracyM   = 100.0 // Lecturas peores se descartan
+	minDistanceM   = 25.0
+	minHeadingDeg  = 30.0
+	maxGapDuration = 2 * time.Minute
+)
+
+// Downsample devuelve, ordenados por hora, solo los puntos que aportan al recorrido
+// (no modifica input). last es el último punto ya guardado de la ruta (nil si no hay):
+// los puntos anteriores a él se descartan (llegaron tarde o duplicados).
+func Downsample(last *domains.Breadcrumb, input []domains.Breadcrumb) []domains.Breadcrumb {
+	points := append([]domains.Breadcrumb(nil), input...)
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].RecordedAt.Before(points[j].RecordedAt)
+	})
+
+	var kept []domains.Breadcrumb
+	prev := last
+	for i := range points {
+		p := points[i]
+		if p.AccuracyM != nil && *p.AccuracyM > MaxAccuracyM {
+			continue
+		}
+		if prev != nil && !p.RecordedAt.After(prev.RecordedAt) {
+			continue
+		}
+		if prev != nil && !significant(prev, &p) {
+			continue
+		}
+		kept = append(kept, p)
+		prev = &kept[len(kept)-1]
+	}
+	return kept
+}
+
+func significant(prev, p *domains.Breadcrumb) bool {
+	if p.RecordedAt.Sub(prev.RecordedAt) >= maxGapDuration {
+		return true
+	}
+	if DistanceM(prev, p) >= minDistanceM {
+		return true
+	}
+	if prev.HeadingDeg != nil && p.HeadingDeg != nil && headingDelta(*prev.HeadingDeg, *p.HeadingDeg) >= minHeadingDeg {
+		return true
+	}
+	return false
+}
+
+// headingDelta es la diferencia angular más corta entre dos rumbos
+func headingDelta(a, b float64) float64 {
+	d := math.Mod(math.Abs(a-b), 360)
+	if d > 180 {
+		d = 360 - d
+	}
+	return d
+}
+
+// DistanceM es la distancia en metros entre dos puntos
+func DistanceM(a, b *domains.Breadcrumb) float64 {
+	return optimization.HaversineDistance(a.Latitude, a.Longitude, b.Latitude, b.Longitude) * 1000
+}
+
+// PathDistanceKm suma la distancia del recorrido real (puntos en orden)
+func PathDistanceKm(points []domains.Breadcrumb) float64 {
+	total := 0.0
+	for i := 1; i < len(points); i++ {
+		total += DistanceM(&points[i-1], &points[i])
+	}
+	return total / 1000
+}
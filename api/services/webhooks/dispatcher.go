@@ -0,0 +1,274 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/domains"
+)
+
+const (
+	dispatchInterval = 5 * time.Second
+	batchSize        = 50 // Máximo de eventos / envíos por pasada
+	claimSize        = 10 // Los envíos se toman y se mandan en paralelo de a este tamaño
+
+	// MaxAttempts: después de esto el envío pasa a "dead"
+	MaxAttempts = 8
+	baseBackoff = 30 * time.Second
+	maxBackoff  = 6 * time.Hour
+
+	// leaseDuration: mientras se envía, nadie más (otra instancia) toma el mismo envío.
+	// Cada tanda de claimSize sale en paralelo y tarda como mucho ~requestTimeout: sobra margen.
+	leaseDuration = time.Minute
+
+	requestTimeout = 10 * time.Second
+)
+
+// Headers que recibe el endpoint
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature" // "sha256=" + HMAC-SHA256(secret, timestamp + "." + body)
+)
+
+var client = newClient()
+
+// StartDispatcher lanza en segundo plano el reparto del outbox y los envíos pendientes
+func StartDispatcher() {
+	go func() {
+		ticker := time.NewTicker(dispatchInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := fanOut(); err != nil {
+				log.Printf("⚠️ Webhooks (outbox): %v", err)
+			}
+			if err := deliverDue(time.Now()); err != nil {
+				log.Printf("⚠️ Webhooks (envíos): %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// fanOut convierte cada evento del outbox en un envío por endpoint suscrito
+func fanOut() error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var events []domains.OutboxEvent
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("processed_at IS NULL").Order("created_at ASC").Limit(batchSize).
+			Find(&events).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, event := range events {
+			var endpoints []domains.WebhookEndpoint
+			if err := tx.Where("admin_id = ? AND is_active = ?", event.AdminID, true).Find(&endpoints).Error; err != nil {
+				return err
+			}
+
+			body, err := json.Marshal(map[string]interface{}{
+				"id":         event.ID,
+				"type":       event.Type,
+				"created_at": event.CreatedAt,
+				"data":       event.Payload,
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, endpoint := range endpoints {
+				if !endpoint.Subscribed(event.Type) {
+					continue
+				}
+				if err := tx.Create(&domains.WebhookDelivery{
+					EndpointID:    endpoint.ID,
+					EventID:       event.ID,
+					EventType:     event.Type,
+					Payload:       body,
+					Status:        domains.DeliveryPending,
+					NextAttemptAt: now,
+				}).Error; err != nil {
+					return err
+				}
+			}
+
+			if err := tx.Model(&domains.OutboxEvent{}).Where("id = ?", event.ID).Update("processed_at", now).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// deliverDue envía los envíos vencidos en tandas pequeñas: cada tanda se toma con su propio
+// "lease" justo antes de mandarla, así el lease no vence mientras espera su turno
+func deliverDue(now time.Time) error {
+	for sent := 0; sent < batchSize; {
+		due, err := claimDue(now)
+		if err != nil {
+			return err
+		}
+		if len(due) == 0 {
+			return nil
+		}
+
+		var wg sync.WaitGroup
+		for i := range due {
+			wg.Add(1)
+			go func(delivery *domains.WebhookDelivery) {
+				defer wg.Done()
+				deliver(delivery)
+			}(&due[i])
+		}
+		wg.Wait()
+
+		sent += len(due)
+		now = time.Now()
+	}
+	return nil
+}
+
+// claimDue toma hasta claimSize envíos vencidos y les corre el próximo intento (lease)
+// para que otra instancia no los tome mientras se envían
+func claimDue(now time.Time) ([]domains.WebhookDelivery, error) {
+	var due []domains.WebhookDelivery
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", domains.DeliveryPending, now).
+			Order("next_attempt_at ASC").Limit(claimSize).
+			Find(&due).Error; err != nil {
+			return err
+		}
+		if len(due) == 0 {
+			return nil
+		}
+
+		ids := make([]interface{}, 0, len(due))
+		for _, d := range due {
+			ids = append(ids, d.ID)
+		}
+		return tx.Model(&domains.WebhookDelivery{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(leaseDuration)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return due, nil
+}
+
+// deliver hace un intento HTTP y registra el resultado
+func deliver(delivery *domains.WebhookDelivery) {
+	var endpoint domains.WebhookEndpoint
+	if err := database.DB.First(&endpoint, "id = ?", delivery.EndpointID).Error; err != nil {
+		// Endpoint borrado: no hay a quién enviarlo
+		database.DB.Model(&domains.WebhookDelivery{}).Where("id = ?", delivery.ID).
+			Updates(map[string]interface{}{"status": domains.DeliveryDead, "last_error": "endpoint eliminado"})
+		return
+	}
+
+	started := time.Now()
+	statusCode, sendErr := send(&endpoint, delivery, started)
+
+	delivery.AttemptCount++
+	attempt := domains.WebhookAttempt{
+		DeliveryID: delivery.ID,
+		Number:     delivery.AttemptCount,
+		StatusCode: statusCode,
+		DurationMs: time.Since(started).Milliseconds(),
+	}
+
+	updates := map[string]interface{}{
+		"attempt_count":    delivery.AttemptCount,
+		"last_status_code": statusCode,
+		"last_error":       "",
+	}
+	if sendErr == nil {
+		updates["status"] = domains.DeliveryDelivered
+		updates["delivered_at"] = time.Now()
+	} else {
+		attempt.Error = sendErr.Error()
+		updates["last_error"] = attempt.Error
+		if delivery.AttemptCount >= MaxAttempts {
+			updates["status"] = domains.DeliveryDead
+			log.Printf("💀 Webhook %s a %s agotó los reintentos: %v", delivery.EventType, endpoint.URL, sendErr)
+		} else {
+			updates["next_attempt_at"] = time.Now().Add(Backoff(delivery.AttemptCount))
+		}
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&attempt).Error; err != nil {
+			return err
+		}
+		return tx.Model(&domains.WebhookDelivery{}).Where("id = ?", delivery.ID).Updates(updates).Error
+	})
+	if err != nil {
+		log.Printf("⚠️ Error registrando intento de webhook %s: %v", delivery.ID, err)
+	}
+}
+
+// send hace el POST firmado. Cualquier respuesta 2xx es éxito.
+func send(endpoint *domains.WebhookEndpoint, delivery *domains.WebhookDelivery, now time.Time) (int, error) {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "route-manager-webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, delivery.ID.String())
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(endpoint.Secret, timestamp, delivery.Payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("respuesta %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign calcula la firma que el receptor debe verificar: HMAC-SHA256(secret, timestamp + "." + body) en hex
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff exponencial: 30s, 1m, 2m, 4m... hasta 6h
+func Backoff(attempt int) time.Duration {
+	wait := baseBackoff
+	for i := 1; i < attempt; i++ {
+		wait *= 2
+		if wait >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return wait
+}
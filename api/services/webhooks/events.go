@@ -0,0 +1,106 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/tu-usuario/route-manager/api/domains"
+)
+
+// Eventos a los que se puede suscribir un endpoint
+const (
+	RouteCreated       = "route.created"
+	RouteStatusChanged = "route.status_changed"
+	WaypointCompleted  = "waypoint.completed"
+	WaypointFailed     = "waypoint.failed"
+	DriverJoined       = "driver.joined"
+)
+
+var EventTypes = []string{RouteCreated, RouteStatusChanged, WaypointCompleted, WaypointFailed, DriverJoined}
+
+// IsEventType valida un nombre de evento
+func IsEventType(name string) bool {
+	for _, t := range EventTypes {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Enqueue escribe el evento en el outbox usando tx: debe ser la misma transacción del cambio
+func Enqueue(tx *gorm.DB, adminID uuid.UUID, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&domains.OutboxEvent{AdminID: adminID, Type: eventType, Payload: payload}).Error
+}
+
+// EnqueueRouteCreated: ruta nueva (a mano, clonada, dividida o generada por plantilla)
+func EnqueueRouteCreated(tx *gorm.DB, route *domains.Route) error {
+	return Enqueue(tx, route.CreatorID, RouteCreated, map[string]interface{}{
+		"route_id":       route.ID,
+		"name":           route.Name,
+		"status":         route.Status,
+		"scheduled_date": route.ScheduledDate,
+		"driver_id":      route.DriverID,
+		"template_id":    route.TemplateID,
+	})
+}
+
+// EnqueueRouteStatusChanged solo encola si el estado realmente cambió
+func EnqueueRouteStatusChanged(tx *gorm.DB, route *domains.Route, from string) error {
+	if from == route.Status {
+		return nil
+	}
+	return Enqueue(tx, route.CreatorID, RouteStatusChanged, map[string]interface{}{
+		"route_id":  route.ID,
+		"from":      from,
+		"to":        route.Status,
+		"driver_id": route.DriverID,
+	})
+}
+
+// EnqueueWaypointCompleted: proof puede ser nil
+func EnqueueWaypointCompleted(tx *gorm.DB, route *domains.Route, wp *domains.Waypoint, proof *domains.ProofOfDelivery) error {
+	data := map[string]interface{}{
+		"waypoint_id":   wp.ID,
+		"route_id":      wp.RouteID,
+		"address":       wp.Address,
+		"customer_name": wp.CustomerName,
+		"completed_at":  wp.CompletedAt,
+		"driver_id":     route.DriverID,
+	}
+	if proof != nil {
+		data["proof_id"] = proof.ID
+		data["recipient_name"] = proof.RecipientName
+		data["geofence_result"] = proof.GeofenceResult
+	}
+	return Enqueue(tx, route.CreatorID, WaypointCompleted, data)
+}
+
+func EnqueueWaypointFailed(tx *gorm.DB, route *domains.Route, wp *domains.Waypoint, attempt *domains.DeliveryAttempt) error {
+	return Enqueue(tx, route.CreatorID, WaypointFailed, map[string]interface{}{
+		"waypoint_id":   wp.ID,
+		"route_id":      wp.RouteID,
+		"address":       wp.Address,
+		"customer_name": wp.CustomerName,
+		"reason_code":   attempt.ReasonCode,
+		"notes":         attempt.Notes,
+		"attempted_at":  attempt.AttemptedAt,
+		"driver_id":     route.DriverID,
+	})
+}
+
+func EnqueueDriverJoined(tx *gorm.DB, adminID uuid.UUID, driver *domains.User) error {
+	return Enqueue(tx, adminID, DriverJoined, map[string]interface{}{
+		"driver_id": driver.ID,
+		"full_name": driver.FullName,
+		"email":     driver.Email,
+		"joined_at": time.Now(),
+	})
+}
@@ -0,0 +1,72 @@
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// El registro de envíos muestra el código HTTP de cada intento: si el webhook pudiera
+// apuntar a la red interna, serviría para sondearla (SSRF). Por eso solo https, sin
+// redirecciones y sin conectarse nunca a direcciones internas, aunque el DNS las devuelva.
+
+var errBlockedAddress = errors.New("la dirección del webhook apunta a una red interna")
+
+// ValidateURL revisa la URL al registrar el webhook (la red se vuelve a validar al conectar)
+func ValidateURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Scheme != "https" || parsed.Hostname() == "" {
+		return errors.New("url inválida (debe ser https)")
+	}
+	host := strings.ToLower(parsed.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errBlockedAddress
+	}
+	if ip := net.ParseIP(host); ip != nil && isBlockedIP(ip) {
+		return errBlockedAddress
+	}
+	return nil
+}
+
+// newClient arma el cliente HTTP de los envíos: valida la IP real al conectar y no sigue redirecciones
+func newClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: requestTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || isBlockedIP(ip) {
+				return fmt.Errorf("%w (%s)", errBlockedAddress, host)
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: requestTimeout,
+		Transport: &http.Transport{
+			Proxy:               nil, // Un proxy saltearía la validación de la IP destino
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: requestTimeout,
+			MaxIdleConns:        20,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse // El 3xx cuenta como respuesta (y como fallo)
+		},
+	}
+}
+
+// isBlockedIP: loopback, privadas (RFC 1918 / ULA), link-local (metadata de la nube) y similares
+func isBlockedIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified()
+}
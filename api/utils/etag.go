@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ETag formatea la versión de un registro como ETag fuerte (ej: "3")
+func ETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// CheckIfMatch valida el header If-Match contra la versión actual.
+// Sin header (o con "*") no se exige nada. Si no coincide responde 412 y devuelve false.
+func CheckIfMatch(c *gin.Context, currentVersion int) bool {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return true
+	}
+
+	current := ETag(currentVersion)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == current {
+			return true
+		}
+	}
+
+	RespondVersionConflict(c, currentVersion)
+	return false
+}
+
+// RespondVersionConflict responde 412 indicando la versión vigente para que el cliente recargue
+func RespondVersionConflict(c *gin.Context, currentVersion int) {
+	c.Header("ETag", ETag(currentVersion))
+	c.JSON(http.StatusPreconditionFailed, gin.H{
+		"error":           "El registro fue modificado por otro usuario. Recarga y vuelve a intentar.",
+		"code":            "VERSION_CONFLICT",
+		"current_version": currentVersion,
+	})
+}
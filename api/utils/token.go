@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// GenerateSecureToken crea un token aleatorio (criptográficamente seguro) de n bytes en hex.
+// Usar para secretos que viajan en URLs públicas (feeds, links de seguimiento...).
+func GenerateSecureToken(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic("no se pudo generar token seguro: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
@@ -1,134 +1,344 @@
-package main
-
-import (
-	"log"
-	"os"
-
-	"github.com/gin-contrib/cors"
-	"github.com/gin-gonic/gin"
-
-	"github.com/tu-usuario/route-manager/api/config"
-	"github.com/tu-usuario/route-manager/api/database"
-	"github.com/tu-usuario/route-manager/api/handlers/auth"
-	"github.com/tu-usuario/route-manager/api/handlers/dashboard"
-	"github.com/tu-usuario/route-manager/api/handlers/health"
-	"github.com/tu-usuario/route-manager/api/handlers/routes"
-	"github.com/tu-usuario/route-manager/api/handlers/users"
-	"github.com/tu-usuario/route-manager/api/handlers/waypoints"
-	"github.com/tu-usuario/route-manager/api/middleware"
-)
-
-func main() {
-	// 1. Cargar Configuración
-	cfg, err := config.Load()
-	if err != nil {
-		log.Fatalf("❌ Error cargando configuración: %v", err)
-	}
-
-	// 2. Inicializar Base de Datos
-	database.InitDB(cfg.DatabaseURL)
-
-	// 3. Configurar Gin
-	if os.Getenv("PORT") != "" {
-		gin.SetMode(gin.ReleaseMode)
-	}
-	router := gin.Default()
-
-	// 4. Configurar CORS
-	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:3000", "https://mi-frontend.vercel.app", "http://127.0.0.1:5500"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-	}))
-
-	// 5. Definir Rutas
-	api := router.Group("/api/v1")
-	{
-		api.GET("/health", health.HealthCheck) // health check del servidor
-
-		// ========== NIVEL 1: AUTENTICACIÓN ==========
-		protected := api.Group("/")
-		protected.Use(middleware.AuthMiddleware(cfg.SupabaseURL))
-		{
-			// A. REGISTRO
-			protected.POST("/auth/register", auth.RegisterUserFromGoogle)
-
-			// B. UNIRSE A FLOTA
-			protected.POST("/users/join-fleet", users.JoinFleet)
-
-			// ========== NIVEL 2: AUTORIZACIÓN ==========
-
-			activeUsers := protected.Group("/")
-			activeUsers.Use(middleware.RequireActiveUser())
-			{
-				// --- USUARIOS ---
-				activeUsers.GET("/users/me", users.GetMe)
-
-				// Rutas SOLO ADMINS (y SUPER ADMINS)
-				adminOnly := activeUsers.Group("/users")
-				// Permitimos que el Super Admin también gestione usuarios
-				adminOnly.Use(middleware.RequireRoles("admin", "super_admin"))
-				{
-					adminOnly.GET("", users.ListUsers)         // Listar todos (Filtrado por lógica de negocio)
-					adminOnly.GET("/:id", users.GetUser)       // Ver otro usuario específico
-					adminOnly.PUT("/:id", users.UpdateUser)    // Editar/Promover usuario
-					adminOnly.DELETE("/:id", users.DeleteUser) // Borrar usuario
-				}
-
-				// --- RUTAS (ROUTES) ---
-				routesGroup := activeUsers.Group("/routes")
-				{
-					// Crear (Admin/SuperAdmin)
-					routesGroup.POST("", middleware.RequireRoles("admin", "super_admin"), routes.CreateRoute)
-
-					// Listar (Admin y Conductor)
-					// Sin middleware de rol: la lógica interna filtra "Mis Rutas" vs "Todas"
-					routesGroup.GET("", routes.ListRoutes)
-
-					// Ver Detalle
-					routesGroup.GET("/:id", routes.GetRouteByID)
-
-					// Editar (Admin/SuperAdmin)
-					routesGroup.PUT("/:id", middleware.RequireRoles("admin", "super_admin"), routes.UpdateRoute)
-
-					// Eliminar (Admin/SuperAdmin)
-					routesGroup.DELETE("/:id", middleware.RequireRoles("admin", "super_admin"), routes.DeleteRoute)
-
-					// Operaciones
-					routesGroup.PATCH("/:id/assign", middleware.RequireRoles("admin", "super_admin"), routes.AssignDriver)
-					routesGroup.PATCH("/:id/status", routes.UpdateRouteStatus)
-
-					// Optimizacion de rutas
-
-					routesGroup.POST("/:id/optimize", middleware.RequireRoles("admin", "super_admin"), routes.OptimizeRoute)
-				}
-
-				// --- WAYPOINTS ---
-				waypointsGroup := activeUsers.Group("/waypoints")
-				{
-					// Completar entrega (Conductor)
-					waypointsGroup.PATCH("/:id/complete", waypoints.MarkWaypointComplete)
-
-					// Editar dirección (Admin/SuperAdmin)
-					waypointsGroup.PUT("/:id", middleware.RequireRoles("admin", "super_admin"), waypoints.UpdateWaypoint)
-				}
-
-				// --- DASHBOARD ---
-				// Accesible para Admin (sus datos) y Super Admin (todo)
-				dashGroup := activeUsers.Group("/dashboard")
-				dashGroup.Use(middleware.RequireRoles("admin", "super_admin"))
-				{
-					dashGroup.GET("/stats", dashboard.GetDashboardStats)
-				}
-			}
-		}
-	}
-
-	// 6. Iniciar Servidor
-	log.Printf("🚀 Route Manager API corriendo en puerto %s", cfg.Port)
-	if err := router.Run(":" + cfg.Port); err != nil {
-		log.Fatalf("❌ Error iniciando servidor: %v", err)
-	}
-}
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+
+	"github.com/tu-usuario/route-manager/api/config"
+	"github.com/tu-usuario/route-manager/api/database"
+	"github.com/tu-usuario/route-manager/api/handlers/auditlog"
+	"github.com/tu-usuario/route-manager/api/handlers/auth"
+	"github.com/tu-usuario/route-manager/api/handlers/availability"
+	"github.com/tu-usuario/route-manager/api/handlers/calendar"
+	"github.com/tu-usuario/route-manager/api/handlers/customernotify"
+	"github.com/tu-usuario/route-manager/api/handlers/dashboard"
+	"github.com/tu-usuario/route-manager/api/handlers/events"
+	"github.com/tu-usuario/route-manager/api/handlers/fleet"
+	"github.com/tu-usuario/route-manager/api/handlers/health"
+	"github.com/tu-usuario/route-manager/api/handlers/incidents"
+	"github.com/tu-usuario/route-manager/api/handlers/inspections"
+	"github.com/tu-usuario/route-manager/api/handlers/messages"
+	"github.com/tu-usuario/route-manager/api/handlers/notifications"
+	"github.com/tu-usuario/route-manager/api/handlers/offline"
+	"github.com/tu-usuario/route-manager/api/handlers/routes"
+	"github.com/tu-usuario/route-manager/api/handlers/templates"
+	"github.com/tu-usuario/route-manager/api/handlers/trash"
+	"github.com/tu-usuario/route-manager/api/handlers/users"
+	"github.com/tu-usuario/route-manager/api/handlers/waypoints"
+	"github.com/tu-usuario/route-manager/api/handlers/webhooks"
+	"github.com/tu-usuario/route-manager/api/middleware"
+	"github.com/tu-usuario/route-manager/api/services/notify"
+	"github.com/tu-usuario/route-manager/api/services/scheduler"
+	webhooksSvc "github.com/tu-usuario/route-manager/api/services/webhooks"
+)
+
+func main() {
+	// 1. Cargar Configuración
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("❌ Error cargando configuración: %v", err)
+	}
+
+	// 2. Inicializar Base de Datos
+	database.InitDB(cfg.DatabaseURL)
+	database.Migrate()
+
+	// 2.1 Procesos en segundo plano
+	scheduler.StartTemplateScheduler(cfg.SchedulerInterval)
+	scheduler.StartTrashPurger(cfg.TrashRetentionDays)
+	scheduler.StartIdempotencyCleanup()
+	webhooksSvc.StartDispatcher()
+	notify.StartSender()
+
+	// 3. Configurar Gin
+	if os.Getenv("PORT") != "" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	router := gin.New()
+	router.Use(middleware.Logger(), gin.Recovery())
+	router.Use(middleware.RequestID())
+
+	// 4. Configurar CORS
+	router.Use(cors.New(cors.Config{
+		AllowOrigins:     []string{"http://localhost:3000", "https://mi-frontend.vercel.app", "http://127.0.0.1:5500"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "If-Match", middleware.RequestIDHeader, middleware.IdempotencyKeyHeader},
+		ExposeHeaders:    []string{"Content-Length", "ETag", middleware.RequestIDHeader, middleware.IdempotencyReplayedHeader},
+		AllowCredentials: true,
+	}))
+
+	// 5. Definir Rutas
+	api := router.Group("/api/v1")
+	{
+		api.GET("/health", health.HealthCheck) // health check del servidor
+
+		// Feed iCal del conductor (público: se autoriza con el token de la URL)
+		api.GET("/drivers/:id/calendar.ics", calendar.DriverCalendarFeed)
+
+		// Seguimiento de entrega para el cliente final (público: se autoriza con el token)
+		api.GET("/track/:token", waypoints.TrackDelivery)
+
+		// Baja de los avisos al cliente final (público: se autoriza con el token del mensaje)
+		api.GET("/unsubscribe/:token", customernotify.Unsubscribe)
+
+		// ========== NIVEL 1: AUTENTICACIÓN ==========
+		authMiddleware := middleware.AuthMiddleware(cfg.SupabaseURL)
+
+		// Progreso de rutas en tiempo real (SSE, mismas reglas de visibilidad que el listado).
+		// Es la única ruta que acepta el token como ?access_token= (EventSource no manda headers)
+		api.GET("/events", middleware.EventStreamToken(), authMiddleware, middleware.RequireActiveUser(), events.StreamEvents)
+
+		protected := api.Group("/")
+		protected.Use(authMiddleware, middleware.Idempotency(cfg.IdempotencyTTL))
+		{
+			// A. REGISTRO
+			protected.POST("/auth/register", auth.RegisterUserFromGoogle)
+
+			// B. UNIRSE A FLOTA
+			protected.POST("/users/join-fleet", users.JoinFleet)
+
+			// ========== NIVEL 2: AUTORIZACIÓN ==========
+
+			activeUsers := protected.Group("/")
+			activeUsers.Use(middleware.RequireActiveUser())
+			{
+				// --- USUARIOS ---
+				activeUsers.GET("/users/me", users.GetMe)
+
+				// Bandeja de notificaciones del propio usuario
+				activeUsers.GET("/notifications", notifications.ListNotifications)
+				activeUsers.PATCH("/notifications/:id/read", notifications.MarkRead)
+				activeUsers.POST("/notifications/read-all", notifications.MarkAllRead)
+
+				// Sincronización offline: cambios desde un cursor / lote de operaciones del conductor
+				activeUsers.GET("/sync", offline.PullChanges)
+				activeUsers.POST("/sync", offline.PushOperations)
+
+				// Feed de calendario del propio usuario
+				activeUsers.GET("/users/me/calendar", calendar.GetMyCalendar)
+				activeUsers.POST("/users/me/calendar-token", calendar.RotateCalendarToken)
+				activeUsers.DELETE("/users/me/calendar-token", calendar.RevokeCalendarToken)
+
+				// Rutas SOLO ADMINS (y SUPER ADMINS)
+				adminOnly := activeUsers.Group("/users")
+				// Permitimos que el Super Admin también gestione usuarios
+				adminOnly.Use(middleware.RequireRoles("admin", "super_admin"))
+				{
+					adminOnly.GET("", users.ListUsers)         // Listar todos (Filtrado por lógica de negocio)
+					adminOnly.GET("/:id", users.GetUser)       // Ver otro usuario específico
+					adminOnly.PUT("/:id", users.UpdateUser)    // Editar/Promover usuario
+					adminOnly.DELETE("/:id", users.DeleteUser) // Borrar usuario (a la papelera)
+					adminOnly.POST("/:id/restore", users.RestoreUser)
+				}
+
+				// --- RUTAS (ROUTES) ---
+				routesGroup := activeUsers.Group("/routes")
+				{
+					// Crear (Admin/SuperAdmin)
+					routesGroup.POST("", middleware.RequireRoles("admin", "super_admin"), routes.CreateRoute)
+
+					// Listar (Admin y Conductor)
+					// Sin middleware de rol: la lógica interna filtra "Mis Rutas" vs "Todas"
+					routesGroup.GET("", routes.ListRoutes)
+
+					// Ver Detalle
+					routesGroup.GET("/:id", routes.GetRouteByID)
+
+					// Editar (Admin/SuperAdmin)
+					routesGroup.PUT("/:id", middleware.RequireRoles("admin", "super_admin"), routes.UpdateRoute)
+
+					// Eliminar (Admin/SuperAdmin)
+					routesGroup.DELETE("/:id", middleware.RequireRoles("admin", "super_admin"), routes.DeleteRoute)
+					routesGroup.POST("/:id/restore", middleware.RequireRoles("admin", "super_admin"), routes.RestoreRoute)
+
+					// Operaciones
+					routesGroup.PATCH("/:id/assign", middleware.RequireRoles("admin", "super_admin"), routes.AssignDriver)
+					routesGroup.POST("/:id/auto-assign", middleware.RequireRoles("admin", "super_admin"), routes.AutoAssignDriver)
+					routesGroup.PATCH("/:id/status", routes.UpdateRouteStatus)
+
+					// Reestructuración (Admin/SuperAdmin)
+					routesGroup.POST("/merge", middleware.RequireRoles("admin", "super_admin"), routes.MergeRoutes)
+
+					// Entregas fallidas: listar y reprogramar en una ruta futura (Admin/SuperAdmin)
+					routesGroup.GET("/failed-stops", middleware.RequireRoles("admin", "super_admin"), routes.ListFailedStops)
+					routesGroup.POST("/failed-stops/move", middleware.RequireRoles("admin", "super_admin"), routes.MoveFailedStops)
+					routesGroup.POST("/:id/clone", middleware.RequireRoles("admin", "super_admin"), routes.CloneRoute)
+					routesGroup.POST("/:id/split", middleware.RequireRoles("admin", "super_admin"), routes.SplitRoute)
+
+					// Historial de cambios (Admin/SuperAdmin)
+					routesGroup.GET("/:id/history", middleware.RequireRoles("admin", "super_admin"), routes.GetRouteHistory)
+
+					// Tiempos de servicio por parada (Admin dueño o Conductor asignado)
+					routesGroup.GET("/:id/service-times", routes.GetRouteServiceTimes)
+
+					// Posición GPS: el conductor envía lotes; el admin ve el recorrido real vs. el plan
+					routesGroup.POST("/:id/track", routes.RecordTrackPoints)
+					routesGroup.GET("/:id/track", middleware.RequireRoles("admin", "super_admin"), routes.GetRouteTrack)
+
+					// Mensajes conductor–despacho (Admin dueño, Conductor asignado o Super Admin)
+					routesGroup.GET("/:id/messages", messages.ListMessages)
+					routesGroup.POST("/:id/messages", messages.SendMessage)
+					routesGroup.POST("/:id/messages/read", messages.MarkThreadRead)
+					routesGroup.GET("/:id/messages/threads", messages.ListThreads)
+
+					// Incidentes en la calle (solo el Conductor asignado reporta)
+					routesGroup.POST("/:id/incidents", incidents.ReportIncident)
+
+					// Inspección pre-viaje (el Conductor asignado la envía antes de iniciar)
+					routesGroup.GET("/:id/inspection", inspections.GetRouteInspection)
+					routesGroup.POST("/:id/inspection", inspections.SubmitInspection)
+
+					// Optimizacion de rutas
+
+					routesGroup.POST("/:id/optimize", middleware.RequireRoles("admin", "super_admin"), routes.OptimizeRoute)
+				}
+
+				// --- PLANTILLAS RECURRENTES ---
+				templatesGroup := activeUsers.Group("/route-templates")
+				templatesGroup.Use(middleware.RequireRoles("admin", "super_admin"))
+				{
+					templatesGroup.POST("", templates.CreateTemplate)
+					templatesGroup.GET("", templates.ListTemplates)
+					templatesGroup.GET("/:id", templates.GetTemplate)
+					templatesGroup.PUT("/:id", templates.UpdateTemplate)
+					templatesGroup.DELETE("/:id", templates.DeleteTemplate)
+
+					// Generar ahora (sin esperar al scheduler)
+					templatesGroup.POST("/:id/generate", templates.GenerateFromTemplate)
+				}
+
+				// --- WAYPOINTS ---
+				waypointsGroup := activeUsers.Group("/waypoints")
+				{
+					// Ver parada (con ETag)
+					waypointsGroup.GET("/:id", waypoints.GetWaypoint)
+
+					// Completar entrega (Conductor)
+					waypointsGroup.PATCH("/:id/complete", waypoints.MarkWaypointComplete)
+
+					// Intento fallido con motivo (Conductor)
+					waypointsGroup.PATCH("/:id/fail", waypoints.MarkWaypointFailed)
+
+					// Deshacer entrega dentro de la ventana de gracia de la flota (Conductor)
+					waypointsGroup.PATCH("/:id/undo-complete", waypoints.UndoCompletion)
+
+					// Llegada / salida (tiempo de servicio) (Conductor)
+					waypointsGroup.PATCH("/:id/arrive", waypoints.ArriveAtWaypoint)
+					waypointsGroup.PATCH("/:id/depart", waypoints.DepartFromWaypoint)
+
+					// Link de seguimiento para el cliente (Admin de la ruta o Conductor asignado)
+					waypointsGroup.POST("/:id/share", waypoints.ShareWaypoint)
+					waypointsGroup.DELETE("/:id/share", waypoints.RevokeWaypointShares)
+
+					// Editar dirección (Admin/SuperAdmin)
+					waypointsGroup.PUT("/:id", middleware.RequireRoles("admin", "super_admin"), waypoints.UpdateWaypoint)
+
+					// Reabrir / corregir una entrega con motivo obligatorio (Admin/SuperAdmin)
+					waypointsGroup.POST("/:id/reopen", middleware.RequireRoles("admin", "super_admin"), waypoints.ReopenWaypoint)
+					waypointsGroup.PATCH("/:id/completion", middleware.RequireRoles("admin", "super_admin"), waypoints.CorrectCompletion)
+				}
+
+				// --- DISPONIBILIDAD DE CONDUCTORES ---
+				availabilityGroup := activeUsers.Group("/availability")
+				{
+					// El propio conductor
+					availabilityGroup.GET("/me", availability.GetMyAvailability)
+					availabilityGroup.PUT("/me/hours", availability.SetMyWorkingHours)
+					availabilityGroup.POST("/me/time-off", availability.RequestTimeOff)
+					availabilityGroup.DELETE("/me/time-off/:id", availability.CancelTimeOff)
+
+					// Su jefe (Admin/SuperAdmin)
+					availabilityGroup.GET("/drivers/:id", middleware.RequireRoles("admin", "super_admin"), availability.GetDriverAvailability)
+					availabilityGroup.GET("/time-off", middleware.RequireRoles("admin", "super_admin"), availability.ListTimeOffRequests)
+					availabilityGroup.PATCH("/time-off/:id/review", middleware.RequireRoles("admin", "super_admin"), availability.ReviewTimeOff)
+				}
+
+				// --- POLÍTICAS DE FLOTA ---
+				fleetGroup := activeUsers.Group("/fleet")
+				fleetGroup.Use(middleware.RequireRoles("admin", "super_admin"))
+				{
+					fleetGroup.GET("/settings", fleet.GetSettings)
+					fleetGroup.PUT("/settings", fleet.UpdateSettings)
+				}
+
+				// --- WEBHOOKS (eventos hacia sistemas externos, por flota) ---
+				webhooksGroup := activeUsers.Group("/webhooks")
+				webhooksGroup.Use(middleware.RequireRoles("admin", "super_admin"))
+				{
+					webhooksGroup.GET("", webhooks.ListEndpoints)
+					webhooksGroup.POST("", webhooks.CreateEndpoint)
+					webhooksGroup.PUT("/:id", webhooks.UpdateEndpoint)
+					webhooksGroup.DELETE("/:id", webhooks.DeleteEndpoint)
+					webhooksGroup.POST("/:id/rotate-secret", webhooks.RotateSecret)
+
+					// Registro de envíos (con cada intento) y reintento de los agotados
+					webhooksGroup.GET("/:id/deliveries", webhooks.ListDeliveries)
+					webhooksGroup.POST("/deliveries/:deliveryId/retry", webhooks.RetryDelivery)
+				}
+
+				// --- AVISOS AL CLIENTE FINAL (email / SMS) ---
+				customerNotifyGroup := activeUsers.Group("/customer-notifications")
+				customerNotifyGroup.Use(middleware.RequireRoles("admin", "super_admin"))
+				{
+					customerNotifyGroup.GET("", customernotify.ListNotifications)
+
+					// Plantillas por evento y canal (sin personalizar se usa la de fábrica)
+					customerNotifyGroup.GET("/templates", customernotify.ListTemplates)
+					customerNotifyGroup.PUT("/templates/:event/:channel", customernotify.UpsertTemplate)
+					customerNotifyGroup.DELETE("/templates/:event/:channel", customernotify.ResetTemplate)
+
+					// Contactos dados de baja
+					customerNotifyGroup.GET("/opt-outs", customernotify.ListOptOuts)
+					customerNotifyGroup.POST("/opt-outs", customernotify.CreateOptOut)
+					customerNotifyGroup.DELETE("/opt-outs/:id", customernotify.DeleteOptOut)
+				}
+
+				// --- CHECKLISTS DE INSPECCIÓN PRE-VIAJE ---
+				checklistsGroup := activeUsers.Group("/inspection-checklists")
+				checklistsGroup.Use(middleware.RequireRoles("admin", "super_admin"))
+				{
+					checklistsGroup.GET("", inspections.ListChecklists)
+					checklistsGroup.POST("", inspections.CreateChecklist)
+					checklistsGroup.GET("/:id", inspections.GetChecklist)
+					checklistsGroup.PUT("/:id", inspections.UpdateChecklist)
+					checklistsGroup.DELETE("/:id", inspections.DeleteChecklist)
+				}
+
+				// --- INCIDENTES ---
+				incidentsGroup := activeUsers.Group("/incidents")
+				{
+					incidentsGroup.GET("", incidents.ListIncidents)
+					incidentsGroup.GET("/:id", incidents.GetIncident)
+					incidentsGroup.PATCH("/:id/acknowledge", middleware.RequireRoles("admin", "super_admin"), incidents.AcknowledgeIncident)
+					incidentsGroup.PATCH("/:id/resolve", middleware.RequireRoles("admin", "super_admin"), incidents.ResolveIncident)
+				}
+
+				// --- PAPELERA ---
+				activeUsers.GET("/trash", middleware.RequireRoles("admin", "super_admin"), trash.ListTrash)
+
+				// --- AUDITORÍA ---
+				activeUsers.GET("/audit", middleware.RequireRoles("admin", "super_admin"), auditlog.SearchAuditLogs)
+
+				// --- DASHBOARD ---
+				// Accesible para Admin (sus datos) y Super Admin (todo)
+				dashGroup := activeUsers.Group("/dashboard")
+				dashGroup.Use(middleware.RequireRoles("admin", "super_admin"))
+				{
+					dashGroup.GET("/stats", dashboard.GetDashboardStats)
+					dashGroup.GET("/exceptions", dashboard.ListExceptions)
+					dashGroup.GET("/service-times", dashboard.GetServiceTimeStats)
+					dashGroup.PATCH("/exceptions/:id/resolve", dashboard.ResolveException)
+				}
+			}
+		}
+	}
+
+	// 6. Iniciar Servidor
+	log.Printf("🚀 Route Manager API corriendo en puerto %s", cfg.Port)
+	if err := router.Run(":" + cfg.Port); err != nil {
+		log.Fatalf("❌ Error iniciando servidor: %v", err)
+	}
+}